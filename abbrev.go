@@ -0,0 +1,111 @@
+package flag
+
+import (
+	"sort"
+	"strings"
+)
+
+// AllowAbbreviations lets flags of two letters or more be abbreviated to
+// any unique prefix of their name, as getopt_long does: --verb for
+// --verbose. Like getopt_long only words starting with two minus signs
+// are abbreviated, so -la stays a cluster of posix short options. An
+// ambiguous prefix fails with an AmbiguousFlagError.
+func (f *FlagSet) AllowAbbreviations(allow bool) *FlagSet {
+	f.abbreviations = allow
+	return f
+}
+
+// AllowAbbreviations lets the command-line flags be abbreviated.
+func AllowAbbreviations(allow bool) {
+	CommandLine.AllowAbbreviations(allow)
+}
+
+// expandFlag returns the full name of the flag abbreviated as name, given
+// with numMinuses minus signs, or name itself when it is not a unique
+// abbreviation.
+func (f *FlagSet) expandFlag(numMinuses int, name string) (string, error) {
+	if !f.abbreviations || numMinuses != 2 || len(name) < 2 || f.lookupInherited(name) != nil {
+		return name, nil
+	}
+
+	var found []*Flag
+	var names []string
+	for _, set := range append([]*FlagSet{f}, f.persistent...) {
+		for _, m := range []map[string]*Flag{set.formal, set.shortLong} {
+			for key, flag := range m {
+				if !strings.HasPrefix(key, name) || strings.Contains(key, ",") {
+					continue
+				}
+
+				if set != f && isGeneratedFlag(flag) {
+					continue
+				}
+
+				// the aliases of a flag share its Value
+				dup := false
+				for _, other := range found {
+					if sameValue(other.Value, flag.Value) && other.negated == flag.negated {
+						dup = true
+						break
+					}
+				}
+
+				if !dup {
+					found = append(found, flag)
+					names = append(names, key)
+				}
+			}
+		}
+	}
+
+	switch len(names) {
+	case 0:
+		return name, nil
+	case 1:
+		return names[0], nil
+	}
+
+	sort.Strings(names)
+	return "", &AmbiguousFlagError{Name: name, Candidates: names}
+}
+
+// AllowAbbreviations lets subcommands be abbreviated to any unique prefix
+// of one of their names. An ambiguous prefix fails with an
+// AmbiguousSubcommandError.
+func (p *ParentCommand) AllowAbbreviations(allow bool) *ParentCommand {
+	p.abbreviations = allow
+	return p
+}
+
+// expandSubCommand returns the name of the subcommand abbreviated as name,
+// or name itself when it is not a unique abbreviation.
+func (p *ParentCommand) expandSubCommand(name string) (string, error) {
+	if !p.abbreviations || name == "" {
+		return name, nil
+	}
+
+	if _, ok := p.subCommand2[name]; ok {
+		return name, nil
+	}
+
+	var names []string
+	for key := range p.subCommand {
+		_, aliases := subCommandNames(key)
+		for _, alias := range aliases {
+			if strings.HasPrefix(alias, name) {
+				names = append(names, alias)
+				break
+			}
+		}
+	}
+
+	switch len(names) {
+	case 0:
+		return name, nil
+	case 1:
+		return names[0], nil
+	}
+
+	sort.Strings(names)
+	return "", &AmbiguousSubcommandError{Name: name, Candidates: names}
+}
@@ -0,0 +1,107 @@
+package flag
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestAllowAbbreviations(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.AllowAbbreviations(true)
+	verbose := fs.Opt("verbose", "verbose output").NewBool(false)
+	header := fs.Opt("H, header", "http header").NewString("")
+	fs.Opt("head", "head request").NewBool(false)
+
+	if err := fs.Parse([]string{"--verb", "--heade", "a:b"}); err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	if !*verbose || *header != "a:b" {
+		t.Errorf("got verbose %t header %s\n", *verbose, *header)
+	}
+
+	err := fs.Parse([]string{"--ver"})
+	e, ok := err.(*AmbiguousFlagError)
+	if !ok || !reflect.DeepEqual(e.Candidates, []string{"verbose", "version"}) {
+		t.Fatalf("got %v want *AmbiguousFlagError\n", err)
+	}
+
+	if err.Error() != "ambiguous flag -ver: could be -verbose, -version" {
+		t.Errorf("got %q\n", err.Error())
+	}
+
+	if err := fs.Parse([]string{"--hea"}); err == nil {
+		t.Errorf("head and header should be ambiguous\n")
+	}
+
+	if err := fs.Parse([]string{"--head"}); err != nil {
+		t.Errorf("an exact name is never ambiguous, got %v\n", err)
+	}
+
+	fs = NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Opt("verbose", "verbose output").NewBool(false)
+	if _, ok := fs.Parse([]string{"--verb"}).(*UnknownFlagError); !ok {
+		t.Errorf("abbreviations should be off by default\n")
+	}
+}
+
+func TestAllowAbbreviationsPosix(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.AllowAbbreviations(true)
+	l := fs.Opt("l", "long listing").Flags(PosixShort).NewBool(false)
+	a := fs.Opt("a", "all").Flags(PosixShort).NewBool(false)
+	label := fs.Opt("label", "label").NewString("")
+
+	if err := fs.Parse([]string{"-la", "x"}); err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	if !*l || !*a || *label != "" || !reflect.DeepEqual(fs.Args(), []string{"x"}) {
+		t.Errorf("-la should be -l -a, got l %t a %t label %q args %v\n", *l, *a, *label, fs.Args())
+	}
+
+	fs.Parse([]string{"--la", "y"})
+	if *label != "y" {
+		t.Errorf("--la should be --label, got %q\n", *label)
+	}
+}
+
+func TestAllowAbbreviationsSubCommand(t *testing.T) {
+	parent := NewParentCommand("tool")
+	parent.SetOutput(ioutil.Discard)
+	parent.AllowAbbreviations(true)
+
+	got := ""
+	parent.SubCommand("ws, websocket", "websocket", func() { got = "websocket" })
+	parent.SubCommand("status", "status", func() { got = "status" })
+	parent.SubCommand("stop", "stop", func() { got = "stop" })
+
+	if err := parent.Parse([]string{"webs"}); err != nil || got != "websocket" {
+		t.Errorf("got %v %s want websocket\n", err, got)
+	}
+
+	if err := parent.Parse([]string{"stat"}); err != nil || got != "status" {
+		t.Errorf("got %v %s want status\n", err, got)
+	}
+
+	err := parent.Parse([]string{"st"})
+	if e, ok := err.(*AmbiguousSubcommandError); !ok || !reflect.DeepEqual(e.Candidates, []string{"status", "stop"}) {
+		t.Errorf("got %v want *AmbiguousSubcommandError\n", err)
+	}
+
+	parent.SubCommand("http", "http", func() { got = "http" })
+	for _, args := range [][]string{{"-h"}, {"h"}, {"--help"}} {
+		got = ""
+		if err := parent.Parse(args); err != ErrHelp || got != "" {
+			t.Errorf("%v: got %v %s want ErrHelp\n", args, err, got)
+		}
+	}
+
+	if _, ok := parent.Parse([]string{"-webs"}).(*UnknownSubcommandError); !ok {
+		t.Errorf("flags should not be abbreviated as subcommands\n")
+	}
+}
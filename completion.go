@@ -0,0 +1,328 @@
+package flag
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// completion describes a command for the shell completion generators.
+type completion struct {
+	path  []string // command path, program name first
+	flags []*Flag
+	subs  []*completionSub
+
+	// dynamic is set when the flags of the command are unknown; the script
+	// then asks the program through the hidden __complete command.
+	dynamic bool
+}
+
+type completionSub struct {
+	names []string // name and aliases, shortest first
+	usage string
+	cmd   *completion
+}
+
+func (f *FlagSet) completion(path []string) *completion {
+	return &completion{path: path, flags: append(sortFlags(f.formal), f.globalFlags()...)}
+}
+
+func (p *ParentCommand) completion(path []string) *completion {
+	c := &completion{path: path}
+	visitGlobal(p.persistentFlags(), nil, func(set *FlagSet, flag *Flag) {
+		c.flags = append(c.flags, flag)
+	})
+
+	for _, sub := range p.sortSubUsage() {
+		names := strings.Split(sub.Name, ", ")
+		subPath := append(append([]string{}, path...), names[len(names)-1])
+
+		cmd := &completion{path: subPath, dynamic: true}
+		switch {
+		case sub.child != nil:
+			cmd = sub.child.completion(subPath)
+		case sub.flagSet != nil:
+			p.inherit(sub.flagSet)
+			cmd = sub.flagSet.completion(subPath)
+		}
+
+		c.subs = append(c.subs, &completionSub{
+			names: names,
+			usage: sub.Usage,
+			cmd:   cmd,
+		})
+	}
+	return c
+}
+
+func (c *completion) walk(fn func(*completion)) {
+	fn(c)
+	for _, sub := range c.subs {
+		sub.cmd.walk(fn)
+	}
+}
+
+func (c *completion) pathString() string {
+	return strings.Join(c.path, " ")
+}
+
+// optionWords returns the names of flag as typed on the command line:
+// -x for one letter names, --name otherwise.
+func optionWords(flag *Flag) []string {
+	var words []string
+	for _, name := range flagNames(flag) {
+		if name == "" {
+			continue
+		}
+
+		if len(name) == 1 {
+			words = append(words, "-"+name)
+		} else {
+			words = append(words, "--"+name)
+		}
+
+		if flag.flags&Negatable > 0 && len(name) > 1 {
+			words = append(words, "--no-"+name)
+		}
+	}
+	return words
+}
+
+// optional interface to indicate flags that only accept a fixed set
+// of values; the values are suggested by shell completion
+type enumFlag interface {
+	Value
+	Enum() []string
+}
+
+// flagEnum returns the values allowed for flag, or nil if any value is allowed.
+func flagEnum(flag *Flag) []string {
+	if len(flag.enum) > 0 {
+		return flag.enum
+	}
+
+	if fv, ok := flag.Value.(enumFlag); ok {
+		return fv.Enum()
+	}
+	return nil
+}
+
+// flagDescription returns the first line of the usage message of flag.
+func flagDescription(flag *Flag) string {
+	_, usage := UnquoteUsage(flag)
+	return firstLine(usage)
+}
+
+func firstLine(s string) string {
+	if pos := strings.Index(s, "\n"); pos != -1 {
+		return s[:pos]
+	}
+	return s
+}
+
+// needsValue reports whether flag consumes the next command-line argument.
+func needsValue(flag *Flag) bool {
+	if flag.flags&(NotValue|OptionalValue) > 0 {
+		return false
+	}
+
+	if fv, ok := flag.Value.(boolFlag); ok && fv.IsBoolFlag() {
+		return false
+	}
+
+	if _, ok := flag.Value.(*boolSlice); ok {
+		return false
+	}
+
+	return true
+}
+
+func progName(name string) string {
+	if name == "" {
+		return "command"
+	}
+	return filepath.Base(name)
+}
+
+// shellIdent turns s into a valid shell function name part.
+func shellIdent(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// shellQuote quotes s for the POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// shellPattern joins words into a quoted shell case pattern.
+func shellPattern(words []string) string {
+	quoted := make([]string, len(words))
+	for k, w := range words {
+		quoted[k] = shellQuote(w)
+	}
+	return strings.Join(quoted, "|")
+}
+
+func genBashCompletion(w io.Writer, root *completion) error {
+	var buf bytes.Buffer
+	prog := root.path[0]
+	fn := "_" + shellIdent(prog) + "_complete"
+
+	fmt.Fprintf(&buf, "# bash completion for %s\n", prog)
+
+	// The program completes itself through the hidden __complete command.
+	// A last line :file asks for file names too.
+	fmt.Fprintf(&buf, "%s() {\n", bashDynamic(prog))
+	buf.WriteString("    local out\n")
+	fmt.Fprintf(&buf, "    out=$(\"${COMP_WORDS[0]}\" %s \"${COMP_WORDS[@]:1:COMP_CWORD}\" 2>/dev/null)\n", completeCommand)
+	fmt.Fprintf(&buf, "    COMPREPLY=( $(compgen -W \"$(printf '%%s\\n' \"$out\" | grep -vx '%s')\" -- \"$cur\") )\n", completeFile)
+	fmt.Fprintf(&buf, "    if [[ $'\\n'\"$out\"$'\\n' == *$'\\n%s\\n'* ]]; then\n", completeFile)
+	buf.WriteString("        COMPREPLY+=( $(compgen -f -- \"$cur\") )\n")
+	buf.WriteString("    fi\n")
+	buf.WriteString("}\n\n")
+
+	fmt.Fprintf(&buf, "%s() {\n", fn)
+	buf.WriteString("    local cur prev cmd i\n")
+	buf.WriteString("    COMPREPLY=()\n")
+	buf.WriteString("    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	buf.WriteString("    prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	fmt.Fprintf(&buf, "    cmd=%s\n", shellQuote(prog))
+
+	if len(root.subs) > 0 {
+		buf.WriteString("\n    for ((i = 1; i < COMP_CWORD; i++)); do\n")
+		buf.WriteString("        case \"$cmd ${COMP_WORDS[i]}\" in\n")
+		root.walk(func(c *completion) {
+			for _, sub := range c.subs {
+				patterns := make([]string, len(sub.names))
+				for k, name := range sub.names {
+					patterns[k] = c.pathString() + " " + name
+				}
+				fmt.Fprintf(&buf, "            %s) cmd=%s ;;\n",
+					shellPattern(patterns), shellQuote(sub.cmd.pathString()))
+			}
+		})
+		buf.WriteString("        esac\n")
+		buf.WriteString("    done\n")
+	}
+
+	buf.WriteString("\n    case \"$cmd\" in\n")
+	root.walk(func(c *completion) {
+		fmt.Fprintf(&buf, "        %s)\n", shellQuote(c.pathString()))
+		writeBashCommand(&buf, c)
+		buf.WriteString("            ;;\n")
+	})
+	buf.WriteString("    esac\n")
+	buf.WriteString("}\n")
+	fmt.Fprintf(&buf, "complete -F %s %s\n", fn, prog)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func bashDynamic(prog string) string {
+	return "__" + shellIdent(prog) + "_dynamic"
+}
+
+func writeBashCommand(buf *bytes.Buffer, c *completion) {
+	const indent = "            "
+
+	dynamic := bashDynamic(c.path[0])
+	if c.dynamic {
+		buf.WriteString(indent + dynamic + "\n")
+		return
+	}
+
+	var words []string
+	var files, values, completers []string
+	var enums []*Flag
+	for _, flag := range c.flags {
+		names := optionWords(flag)
+		words = append(words, names...)
+		if len(names) == 0 || !needsValue(flag) {
+			continue
+		}
+
+		switch {
+		case flag.completer != nil:
+			completers = append(completers, names...)
+		case flagEnum(flag) != nil:
+			enums = append(enums, flag)
+		case flag.flags&FileName > 0:
+			files = append(files, names...)
+		default:
+			values = append(values, names...)
+		}
+	}
+
+	if len(files) > 0 || len(values) > 0 || len(enums) > 0 || len(completers) > 0 {
+		buf.WriteString(indent + "case \"$prev\" in\n")
+		if len(completers) > 0 {
+			fmt.Fprintf(buf, indent+"    %s)\n", shellPattern(completers))
+			buf.WriteString(indent + "        " + dynamic + "\n")
+			buf.WriteString(indent + "        return 0\n")
+			buf.WriteString(indent + "        ;;\n")
+		}
+		for _, flag := range enums {
+			fmt.Fprintf(buf, indent+"    %s)\n", shellPattern(optionWords(flag)))
+			fmt.Fprintf(buf, indent+"        COMPREPLY=( $(compgen -W %s -- \"$cur\") )\n",
+				shellQuote(strings.Join(flagEnum(flag), " ")))
+			buf.WriteString(indent + "        return 0\n")
+			buf.WriteString(indent + "        ;;\n")
+		}
+		if len(files) > 0 {
+			fmt.Fprintf(buf, indent+"    %s)\n", shellPattern(files))
+			buf.WriteString(indent + "        COMPREPLY=( $(compgen -f -- \"$cur\") )\n")
+			buf.WriteString(indent + "        return 0\n")
+			buf.WriteString(indent + "        ;;\n")
+		}
+		if len(values) > 0 {
+			fmt.Fprintf(buf, indent+"    %s)\n", shellPattern(values))
+			buf.WriteString(indent + "        return 0\n")
+			buf.WriteString(indent + "        ;;\n")
+		}
+		buf.WriteString(indent + "esac\n")
+	}
+
+	if len(words) > 0 {
+		buf.WriteString(indent + "if [[ \"$cur\" == -* ]]; then\n")
+		fmt.Fprintf(buf, indent+"    COMPREPLY=( $(compgen -W %s -- \"$cur\") )\n", shellQuote(strings.Join(words, " ")))
+		buf.WriteString(indent + "    return 0\n")
+		buf.WriteString(indent + "fi\n")
+	}
+
+	if len(c.subs) > 0 {
+		var names []string
+		for _, sub := range c.subs {
+			names = append(names, sub.names...)
+		}
+		fmt.Fprintf(buf, indent+"COMPREPLY=( $(compgen -W %s -- \"$cur\") )\n", shellQuote(strings.Join(names, " ")))
+		return
+	}
+
+	buf.WriteString(indent + "COMPREPLY=( $(compgen -f -- \"$cur\") )\n")
+}
+
+// GenBashCompletion writes a bash completion script for the flag set to w.
+// Flags marked with FileName complete their value with file names.
+func (f *FlagSet) GenBashCompletion(w io.Writer) error {
+	return genBashCompletion(w, f.completion([]string{progName(f.name)}))
+}
+
+// GenBashCompletion writes a bash completion script for the command and
+// its subcommands to w.
+func (p *ParentCommand) GenBashCompletion(w io.Writer) error {
+	return genBashCompletion(w, p.completion([]string{progName(p.name)}))
+}
+
+// GenBashCompletion writes a bash completion script for the command-line flags to w.
+func GenBashCompletion(w io.Writer) error {
+	return CommandLine.GenBashCompletion(w)
+}
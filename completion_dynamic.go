@@ -0,0 +1,277 @@
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Completer returns the candidates, starting with prefix, for the value of a flag.
+type Completer func(prefix string) []string
+
+// ErrComplete is the error returned by Parse after it answered a request of
+// the hidden __complete command used by the generated completion scripts.
+var ErrComplete = errors.New("flag: completion requested")
+
+// completeOutput receives the answers to __complete requests.
+var completeOutput io.Writer = os.Stdout
+
+const (
+	// completeCommand is the hidden first argument asking for completions.
+	completeCommand = "__complete"
+	// completeFile is printed after the candidates when file names apply too.
+	completeFile = ":file"
+)
+
+// Completer sets the function suggesting values for the flag when the
+// command line is completed at runtime by the shell.
+func (f *Flag) Completer(fn Completer) *Flag {
+	f.completer = fn
+	return f
+}
+
+// lookupFlag finds the flag named name, without side effects.
+func (f *FlagSet) lookupFlag(name string) *Flag {
+	if flag, ok := f.formal[name]; ok {
+		return flag
+	}
+	return f.shortLong[name]
+}
+
+// completeValue returns the values of flag starting with cur, each one
+// preceded by prefix.
+func (f *FlagSet) completeValue(flag *Flag, prefix string, cur string) []string {
+	var values []string
+	switch {
+	case flag.completer != nil:
+		values = flag.completer(cur)
+	case flagEnum(flag) != nil:
+		for _, v := range flagEnum(flag) {
+			if strings.HasPrefix(v, cur) {
+				values = append(values, v)
+			}
+		}
+	}
+
+	for k := range values {
+		values[k] = prefix + values[k]
+	}
+
+	if flag.completer == nil && flagEnum(flag) == nil && flag.flags&FileName > 0 {
+		values = append(values, completeFile)
+	}
+	return values
+}
+
+// completePosix completes a cluster of posix short options such as -TE.
+func (f *FlagSet) completePosix(cur string) []string {
+	cluster := cur[1:]
+	used := make(map[string]bool)
+	for i := range cluster {
+		name := string(cluster[i])
+		flag := f.lookupFlag(name)
+		if flag == nil || flag.flags&PosixShort == 0 {
+			return nil
+		}
+
+		if needsValue(flag) {
+			return f.completeValue(flag, cur[:i+2], cluster[i+1:])
+		}
+		used[name] = true
+	}
+
+	var candidates []string
+	if len(cluster) > 1 {
+		candidates = append(candidates, cur)
+	}
+
+	for _, m := range []map[string]*Flag{f.formal, f.shortLong} {
+		for name, flag := range m {
+			if len(name) != 1 || used[name] || flag.flags&PosixShort == 0 {
+				continue
+			}
+			used[name] = true
+			candidates = append(candidates, cur+name)
+		}
+	}
+	return candidates
+}
+
+// completeFlag completes cur, a word starting with a minus sign.
+func (f *FlagSet) completeFlag(cur string) []string {
+	numMinuses := 1
+	if strings.HasPrefix(cur, "--") {
+		numMinuses = 2
+	}
+
+	if name, hasValue, value := parseNameValue(cur[numMinuses:]); hasValue {
+		flag := f.lookupInherited(name)
+		if flag == nil {
+			return nil
+		}
+		return f.completeValue(flag, cur[:len(cur)-len(value)], value)
+	}
+
+	var candidates []string
+	for _, flag := range append(sortFlags(f.formal), f.globalFlags()...) {
+		for _, word := range optionWords(flag) {
+			if strings.HasPrefix(word, cur) {
+				candidates = append(candidates, word)
+			}
+		}
+	}
+
+	if numMinuses == 1 && len(cur) > 1 {
+		candidates = append(candidates, f.completePosix(cur)...)
+	}
+
+	sort.Strings(candidates)
+	return uniqStrings(candidates)
+}
+
+// complete returns the candidates for the last element of args, the word
+// being completed; the previous elements are the words before it.
+func (f *FlagSet) complete(args []string) []string {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+
+	cur := args[len(args)-1]
+
+	var pending *Flag
+	terminated := false
+	for _, w := range args[:len(args)-1] {
+		switch {
+		case pending != nil:
+			pending = nil
+		case terminated || len(w) < 2 || w[0] != '-':
+		case w == "--":
+			terminated = true
+		default:
+			name := strings.TrimLeft(w, "-")
+			if _, hasValue, _ := parseNameValue(name); hasValue {
+				continue
+			}
+
+			if flag := f.lookupInherited(name); flag != nil {
+				if needsValue(flag) {
+					pending = flag
+				}
+				continue
+			}
+
+			// a posix cluster ending with an option that needs a value, -iA
+			if w[1] != '-' {
+				flag := f.lookupFlag(name[len(name)-1:])
+				if flag != nil && flag.flags&PosixShort > 0 && needsValue(flag) {
+					pending = flag
+				}
+			}
+		}
+	}
+
+	if pending != nil {
+		return f.completeValue(pending, "", cur)
+	}
+
+	if !terminated && strings.HasPrefix(cur, "-") {
+		return f.completeFlag(cur)
+	}
+
+	return []string{completeFile}
+}
+
+// uniqStrings removes adjacent duplicates from the sorted slice s.
+func uniqStrings(s []string) []string {
+	if len(s) == 0 {
+		return s
+	}
+
+	out := s[:1]
+	for _, v := range s[1:] {
+		if v != out[len(out)-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func printCompletions(w io.Writer, candidates []string) {
+	for _, c := range candidates {
+		fmt.Fprintln(w, c)
+	}
+}
+
+// complete answers the __complete request for the subcommands.
+func (p *ParentCommand) complete(args []string) error {
+	fs := p.bindPersistent()
+	if fs != nil {
+		// skip the persistent flags before the subcommand name
+		for len(args) > 1 && len(args[0]) > 1 && args[0][0] == '-' {
+			name, hasValue, _ := parseNameValue(strings.TrimLeft(args[0], "-"))
+			flag := fs.lookupInherited(name)
+			args = args[1:]
+			if flag == nil || hasValue || !needsValue(flag) {
+				continue
+			}
+
+			if len(args) == 1 {
+				printCompletions(completeOutput, fs.completeValue(flag, "", args[0]))
+				return ErrComplete
+			}
+			args = args[1:]
+		}
+	}
+
+	if len(args) > 1 {
+		sub, ok := p.subCommand2[args[0]]
+		if !ok {
+			return ErrComplete
+		}
+
+		if sub.child != nil {
+			return sub.child.complete(args[1:])
+		}
+
+		if sub.flagSet != nil {
+			p.inherit(sub.flagSet)
+			printCompletions(completeOutput, sub.flagSet.complete(args[1:]))
+			return ErrComplete
+		}
+
+		// the function of a SubCommand is the command itself, never run it
+		// to complete; use Command to complete its flags
+		printCompletions(completeOutput, []string{completeFile})
+		return ErrComplete
+	}
+
+	cur := ""
+	if len(args) == 1 {
+		cur = args[0]
+	}
+
+	var candidates []string
+	for name := range p.subCommand2 {
+		if strings.HasPrefix(name, cur) {
+			candidates = append(candidates, name)
+		}
+	}
+
+	words := []string{"-h", "--help"}
+	visitGlobal(p.persistentFlags(), nil, func(set *FlagSet, flag *Flag) {
+		words = append(words, optionWords(flag)...)
+	})
+
+	for _, name := range words {
+		if cur != "" && strings.HasPrefix(name, cur) {
+			candidates = append(candidates, name)
+		}
+	}
+
+	sort.Strings(candidates)
+	printCompletions(completeOutput, candidates)
+	return ErrComplete
+}
@@ -0,0 +1,124 @@
+package flag
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// fishQuote quotes s for the fish shell.
+func fishQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(s) + "'"
+}
+
+func writeFishCommand(buf *bytes.Buffer, prefix string, c *completion) {
+	cond := fmt.Sprintf("-n %s", fishQuote(fmt.Sprintf("%s_is %s", prefix, fishQuote(c.pathString()))))
+
+	for _, flag := range c.flags {
+		names := flagNames(flag)
+		if len(names) == 0 || names[0] == "" {
+			continue
+		}
+
+		fmt.Fprintf(buf, "complete -c %s %s", c.path[0], cond)
+		for _, name := range names {
+			if len(name) == 1 {
+				fmt.Fprintf(buf, " -s %s", name)
+			} else {
+				fmt.Fprintf(buf, " -l %s", name)
+			}
+		}
+
+		fmt.Fprintf(buf, " -d %s", fishQuote(flagDescription(flag)))
+		if needsValue(flag) {
+			switch {
+			case flag.completer != nil:
+				fmt.Fprintf(buf, " -x -a '(%s_dynamic)'", prefix)
+			case flagEnum(flag) != nil:
+				fmt.Fprintf(buf, " -x -a %s", fishQuote(strings.Join(flagEnum(flag), " ")))
+			case flag.flags&FileName > 0:
+				buf.WriteString(" -r -F")
+			default:
+				buf.WriteString(" -x")
+			}
+		}
+		buf.WriteString("\n")
+	}
+
+	if c.dynamic {
+		fmt.Fprintf(buf, "complete -c %s %s -a '(%s_dynamic)'\n", c.path[0], cond, prefix)
+	}
+
+	for _, sub := range c.subs {
+		for _, name := range sub.names {
+			fmt.Fprintf(buf, "complete -c %s %s -f -a %s -d %s\n",
+				c.path[0], cond, fishQuote(name), fishQuote(firstLine(sub.usage)))
+		}
+	}
+}
+
+func genFishCompletion(w io.Writer, root *completion) error {
+	var buf bytes.Buffer
+	prog := root.path[0]
+	prefix := "__" + shellIdent(prog)
+
+	fmt.Fprintf(&buf, "# fish completion for %s\n\n", prog)
+
+	// <prog>_command prints the path of the command being completed.
+	fmt.Fprintf(&buf, "function %s_command\n", prefix)
+	buf.WriteString("    set -l words (commandline -opc)\n")
+	fmt.Fprintf(&buf, "    set -l cmd %s\n", fishQuote(prog))
+	buf.WriteString("    for w in $words[2..-1]\n")
+	buf.WriteString("        switch \"$cmd $w\"\n")
+	root.walk(func(c *completion) {
+		for _, sub := range c.subs {
+			patterns := make([]string, len(sub.names))
+			for k, name := range sub.names {
+				patterns[k] = fishQuote(c.pathString() + " " + name)
+			}
+			fmt.Fprintf(&buf, "            case %s\n", strings.Join(patterns, " "))
+			fmt.Fprintf(&buf, "                set cmd %s\n", fishQuote(sub.cmd.pathString()))
+		}
+	})
+	buf.WriteString("        end\n")
+	buf.WriteString("    end\n")
+	buf.WriteString("    echo $cmd\n")
+	buf.WriteString("end\n\n")
+
+	// <prog>_dynamic asks the program through the hidden __complete command.
+	fmt.Fprintf(&buf, "function %s_dynamic\n", prefix)
+	buf.WriteString("    set -l args (commandline -opc)\n")
+	buf.WriteString("    set -l cur (commandline -ct)\n")
+	fmt.Fprintf(&buf, "    $args[1] %s $args[2..-1] \"$cur\" 2>/dev/null | string match -v -- %s\n", completeCommand, completeFile)
+	buf.WriteString("end\n\n")
+
+	fmt.Fprintf(&buf, "function %s_is\n", prefix)
+	fmt.Fprintf(&buf, "    test (%s_command) = \"$argv\"\n", prefix)
+	buf.WriteString("end\n\n")
+
+	root.walk(func(c *completion) {
+		writeFishCommand(&buf, prefix, c)
+	})
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// GenFishCompletion writes a fish completion script for the flag set to w.
+// The usage message of each flag is shown as its description.
+func (f *FlagSet) GenFishCompletion(w io.Writer) error {
+	return genFishCompletion(w, f.completion([]string{progName(f.name)}))
+}
+
+// GenFishCompletion writes a fish completion script for the command and
+// its subcommands to w.
+func (p *ParentCommand) GenFishCompletion(w io.Writer) error {
+	return genFishCompletion(w, p.completion([]string{progName(p.name)}))
+}
+
+// GenFishCompletion writes a fish completion script for the command-line flags to w.
+func GenFishCompletion(w io.Writer) error {
+	return CommandLine.GenFishCompletion(w)
+}
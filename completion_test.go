@@ -0,0 +1,274 @@
+package flag
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func newCompletionFlagSet() *FlagSet {
+	fs := NewFlagSet("/usr/bin/my-tool", ContinueOnError)
+	fs.Opt("f, file", "input file").Flags(FileName).NewString("")
+	fs.Opt("p, port", "listen port").NewInt(80)
+	fs.Opt("d, debug", "debug mode").NewBool(false)
+	return fs
+}
+
+func TestGenBashCompletion(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newCompletionFlagSet().GenBashCompletion(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, need := range []string{
+		"_my_tool_complete() {",
+		"'-f'|'--file')\n                    COMPREPLY=( $(compgen -f -- \"$cur\") )",
+		"'-p'|'--port')\n                    return 0",
+		"compgen -W '-V --version -d --debug -f --file -h --help -p --port'",
+		"complete -F _my_tool_complete my-tool",
+	} {
+		if !strings.Contains(out, need) {
+			t.Errorf("bash completion should contain %q, got\n%s", need, out)
+		}
+	}
+}
+
+func TestGenBashCompletionSubCommand(t *testing.T) {
+	parent := NewParentCommand("git")
+	parent.SubCommand("clone", "Clone a repository into a new directory", func() {})
+	parent.SubCommand("tcp, udp", "Use the tcp or udp subcommand", func() {})
+
+	var buf bytes.Buffer
+	if err := parent.GenBashCompletion(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, need := range []string{
+		"'git clone') cmd='git clone' ;;",
+		"'git tcp'|'git udp') cmd='git udp' ;;",
+		"COMPREPLY=( $(compgen -W 'clone tcp udp' -- \"$cur\") )",
+	} {
+		if !strings.Contains(out, need) {
+			t.Errorf("bash completion should contain %q, got\n%s", need, out)
+		}
+	}
+}
+
+type formatValue string
+
+func (f *formatValue) String() string     { return string(*f) }
+func (f *formatValue) Set(s string) error { *f = formatValue(s); return nil }
+func (f *formatValue) Enum() []string     { return []string{"json", "yaml"} }
+
+func newCompletionParent() *ParentCommand {
+	parent := NewParentCommand("tool")
+	parent.SubCommand("http", "Use the http subcommand", func() {})
+	parent.SubCommand("ws, websocket", "Use the websocket: subcommand", func() {})
+	return parent
+}
+
+func TestGenZshCompletion(t *testing.T) {
+	var format formatValue
+	fs := newCompletionFlagSet()
+	fs.Var(&format, "o, output", "output `format`")
+	fs.Opt("H, header", "http [header]").NewStringSlice([]string{})
+
+	var buf bytes.Buffer
+	if err := fs.GenZshCompletion(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, need := range []string{
+		"#compdef my-tool",
+		"function _my_tool {",
+		`'(-f --file)'{-f,--file}'[input file]:string:_files'`,
+		`'(-d --debug)'{-d,--debug}'[debug mode]'`,
+		`'(-o --output)'{-o,--output}'[output format]:format:(json yaml)'`,
+		`'*'{-H,--header}'[http \[header\]]:string\[\]:'`,
+		"compdef _my_tool my-tool",
+	} {
+		if !strings.Contains(out, need) {
+			t.Errorf("zsh completion should contain %q, got\n%s", need, out)
+		}
+	}
+
+	buf.Reset()
+	if err := newCompletionParent().GenZshCompletion(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	out = buf.String()
+	for _, need := range []string{
+		"'http:Use the http subcommand'",
+		`'websocket:Use the websocket\: subcommand'`,
+		"'ws'|'websocket') _tool_websocket ;;",
+		"function _tool_websocket {",
+	} {
+		if !strings.Contains(out, need) {
+			t.Errorf("zsh completion should contain %q, got\n%s", need, out)
+		}
+	}
+}
+
+func TestGenFishCompletion(t *testing.T) {
+	var format formatValue
+	fs := newCompletionFlagSet()
+	fs.Var(&format, "o, output", "output `format`")
+
+	var buf bytes.Buffer
+	if err := fs.GenFishCompletion(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, need := range []string{
+		`complete -c my-tool -n '__my_tool_is \'my-tool\'' -s f -l file -d 'input file' -r -F`,
+		`complete -c my-tool -n '__my_tool_is \'my-tool\'' -s d -l debug -d 'debug mode'` + "\n",
+		`-s o -l output -d 'output format' -x -a 'json yaml'`,
+	} {
+		if !strings.Contains(out, need) {
+			t.Errorf("fish completion should contain %q, got\n%s", need, out)
+		}
+	}
+
+	buf.Reset()
+	if err := newCompletionParent().GenFishCompletion(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	out = buf.String()
+	for _, need := range []string{
+		"case 'tool ws' 'tool websocket'\n                set cmd 'tool websocket'",
+		`complete -c tool -n '__tool_is \'tool\'' -f -a 'http' -d 'Use the http subcommand'`,
+	} {
+		if !strings.Contains(out, need) {
+			t.Errorf("fish completion should contain %q, got\n%s", need, out)
+		}
+	}
+}
+
+func newDynamicFlagSet() *FlagSet {
+	fs := NewFlagSet("cat", ContinueOnError)
+	fs.Opt("T, show-tabs", "display TAB characters as ^I").Flags(PosixShort).NewBool(false)
+	fs.Opt("E, show-ends", "display $ at end of each line").Flags(PosixShort).NewBool(false)
+	fs.Opt("A, after-context", "print NUM lines of trailing context").Flags(PosixShort).NewString("")
+	fs.Opt("host", "server host").Completer(func(prefix string) []string {
+		var hosts []string
+		for _, h := range []string{"alpha", "beta", "alpine"} {
+			if strings.HasPrefix(h, prefix) {
+				hosts = append(hosts, h)
+			}
+		}
+		return hosts
+	}).NewString("")
+	fs.Opt("f, file", "input file").Flags(FileName).NewString("")
+	return fs
+}
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"--sh"}, []string{"--show-ends", "--show-tabs"}},
+		{[]string{"--host", "al"}, []string{"alpha", "alpine"}},
+		{[]string{"--host=b"}, []string{"--host=beta"}},
+		{[]string{"-f", ""}, []string{":file"}},
+		{[]string{"-TE"}, []string{"-TE", "-TEA"}},
+		{[]string{"-TA"}, nil},
+		{[]string{"-T", ""}, []string{":file"}},
+		{[]string{"--", "-"}, []string{":file"}},
+	}
+
+	for _, test := range tests {
+		got := newDynamicFlagSet().complete(test.args)
+		if strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("complete(%q) got %q want %q\n", test.args, got, test.want)
+		}
+	}
+}
+
+func TestCompleteParse(t *testing.T) {
+	var buf bytes.Buffer
+	completeOutput = &buf
+	defer func() { completeOutput = os.Stdout }()
+
+	parent := NewParentCommand("tool")
+	fs := parent.Command("cat", "concatenate files", nil)
+	fs.Opt("host", "server host").Completer(func(prefix string) []string {
+		return []string{"beta"}
+	}).NewString("")
+
+	ran := false
+	parent.SubCommand("ws, websocket", "websocket", func() { ran = true })
+
+	for _, args := range [][]string{
+		{"__complete", "w"},
+		{"__complete", "cat", "--host", "b"},
+		{"__complete", "ws", "--"},
+	} {
+		if err := parent.Parse(args); err != ErrComplete {
+			t.Errorf("got %v want ErrComplete\n", err)
+		}
+	}
+
+	if ran {
+		t.Errorf("completion must not run the function of a SubCommand\n")
+	}
+
+	if buf.String() != "websocket\nws\nbeta\n:file\n" {
+		t.Errorf("got %q\n", buf.String())
+	}
+}
+
+func TestGenBashCompletionNested(t *testing.T) {
+	parent := NewParentCommand("tool")
+	cluster := parent.SubParentCommand("cluster", "Manage clusters")
+	cluster.SubCommand("add", "Add a node", func() {})
+
+	var buf bytes.Buffer
+	if err := parent.GenBashCompletion(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, need := range []string{
+		"'tool cluster add') cmd='tool cluster add' ;;",
+		"COMPREPLY=( $(compgen -W 'add' -- \"$cur\") )",
+	} {
+		if !strings.Contains(out, need) {
+			t.Errorf("bash completion should contain %q, got\n%s", need, out)
+		}
+	}
+}
+
+func TestCompletePersistent(t *testing.T) {
+	var buf bytes.Buffer
+	completeOutput = &buf
+	defer func() { completeOutput = os.Stdout }()
+
+	parent := NewParentCommand("tool")
+	parent.PersistentFlags().Opt("v, verbose", "verbose output").NewBool(false)
+	parent.PersistentFlags().Var(new(formatValue), "format", "output format")
+	http := parent.Command("http", "Use the http subcommand", nil)
+	http.Opt("url", "Specify a URL to fetch").NewString("")
+
+	for _, args := range [][]string{
+		{"__complete", "--v"},
+		{"__complete", "-v", "--format", "y"},
+		{"__complete", "--format", "json", "http", "--"},
+	} {
+		if err := parent.Parse(args); err != ErrComplete {
+			t.Errorf("got %v want ErrComplete\n", err)
+		}
+	}
+
+	want := "--verbose\nyaml\n--format\n--help\n--url\n--verbose\n--version\n"
+	if buf.String() != want {
+		t.Errorf("got %q want %q\n", buf.String(), want)
+	}
+}
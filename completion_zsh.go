@@ -0,0 +1,171 @@
+package flag
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// zshEscape escapes s for use inside an _arguments or _describe spec
+// enclosed in single quotes.
+func zshEscape(s string) string {
+	r := strings.NewReplacer(`'`, `'\''`, `\`, `\\`, `[`, `\[`, `]`, `\]`, `:`, `\:`)
+	return r.Replace(s)
+}
+
+func zshFunction(c *completion) string {
+	return "_" + shellIdent(strings.Join(c.path, "_"))
+}
+
+func zshDynamic(prog string) string {
+	return "__" + shellIdent(prog) + "_dynamic"
+}
+
+// zshFlagSpec returns the _arguments spec of flag. Values of flags with a
+// Completer are completed by the function dynamic.
+func zshFlagSpec(flag *Flag, dynamic string) string {
+	words := optionWords(flag)
+	if len(words) == 0 {
+		return ""
+	}
+
+	var spec bytes.Buffer
+	switch {
+	case isSliceValue(flag.Value):
+		spec.WriteString("'*'")
+	case len(words) > 1:
+		fmt.Fprintf(&spec, "'(%s)'", strings.Join(words, " "))
+	}
+
+	if len(words) > 1 {
+		fmt.Fprintf(&spec, "{%s}", strings.Join(words, ","))
+	} else {
+		spec.WriteString(words[0])
+	}
+
+	fmt.Fprintf(&spec, "'[%s]", zshEscape(flagDescription(flag)))
+
+	if needsValue(flag) {
+		name, _ := UnquoteUsage(flag)
+		if name == "" {
+			name = "value"
+		}
+
+		fmt.Fprintf(&spec, ":%s:", zshEscape(name))
+		switch {
+		case flag.completer != nil:
+			spec.WriteString(dynamic)
+		case flagEnum(flag) != nil:
+			values := append([]string(nil), flagEnum(flag)...)
+			for k := range values {
+				values[k] = zshEscape(values[k])
+			}
+			fmt.Fprintf(&spec, "(%s)", strings.Join(values, " "))
+		case flag.flags&FileName > 0:
+			spec.WriteString("_files")
+		}
+	}
+
+	spec.WriteString("'")
+	return spec.String()
+}
+
+func writeZshCommand(buf *bytes.Buffer, c *completion) {
+	dynamic := zshDynamic(c.path[0])
+
+	fmt.Fprintf(buf, "function %s {\n", zshFunction(c))
+	if c.dynamic {
+		fmt.Fprintf(buf, "    %s\n", dynamic)
+		buf.WriteString("}\n\n")
+		return
+	}
+
+	if len(c.subs) > 0 {
+		buf.WriteString("    local line state\n\n")
+	}
+
+	buf.WriteString("    _arguments -C")
+	for _, flag := range c.flags {
+		if spec := zshFlagSpec(flag, dynamic); spec != "" {
+			fmt.Fprintf(buf, " \\\n        %s", spec)
+		}
+	}
+
+	if len(c.subs) == 0 {
+		buf.WriteString(" \\\n        '*:file:_files'\n")
+		buf.WriteString("}\n\n")
+		return
+	}
+
+	buf.WriteString(" \\\n        '1: :->cmds' \\\n        '*::arg:->args'\n\n")
+	buf.WriteString("    case $state in\n")
+	buf.WriteString("        cmds)\n")
+	buf.WriteString("            local -a commands\n")
+	buf.WriteString("            commands=(\n")
+	for _, sub := range c.subs {
+		for _, name := range sub.names {
+			fmt.Fprintf(buf, "                '%s:%s'\n", zshEscape(name), zshEscape(firstLine(sub.usage)))
+		}
+	}
+	buf.WriteString("            )\n")
+	buf.WriteString("            _describe 'command' commands\n")
+	buf.WriteString("            ;;\n")
+	buf.WriteString("        args)\n")
+	buf.WriteString("            case $line[1] in\n")
+	for _, sub := range c.subs {
+		fmt.Fprintf(buf, "                %s) %s ;;\n", shellPattern(sub.names), zshFunction(sub.cmd))
+	}
+	buf.WriteString("            esac\n")
+	buf.WriteString("            ;;\n")
+	buf.WriteString("    esac\n")
+	buf.WriteString("}\n\n")
+}
+
+func genZshCompletion(w io.Writer, root *completion) error {
+	var buf bytes.Buffer
+	prog := root.path[0]
+	fn := zshFunction(root)
+
+	fmt.Fprintf(&buf, "#compdef %s\n\n", prog)
+
+	// The program completes itself through the hidden __complete command.
+	// A last line :file asks for file names too.
+	fmt.Fprintf(&buf, "function %s {\n", zshDynamic(prog))
+	buf.WriteString("    local -a args candidates\n")
+	buf.WriteString("    args=(${(z)LBUFFER})\n")
+	buf.WriteString("    [[ $LBUFFER == *[[:space:]] ]] && args+=('')\n")
+	fmt.Fprintf(&buf, "    candidates=(${(f)\"$(${args[1]} %s \"${(@)args[2,-1]}\" 2>/dev/null)\"})\n", completeCommand)
+	fmt.Fprintf(&buf, "    compadd -- ${candidates:#%s}\n", completeFile)
+	fmt.Fprintf(&buf, "    (( ${candidates[(I)%s]} )) && _files\n", completeFile)
+	buf.WriteString("}\n\n")
+	root.walk(func(c *completion) {
+		writeZshCommand(&buf, c)
+	})
+
+	fmt.Fprintf(&buf, "if [ \"$funcstack[1]\" = \"%s\" ]; then\n", fn)
+	fmt.Fprintf(&buf, "    %s \"$@\"\n", fn)
+	buf.WriteString("else\n")
+	fmt.Fprintf(&buf, "    compdef %s %s\n", fn, prog)
+	buf.WriteString("fi\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// GenZshCompletion writes a zsh completion script for the flag set to w.
+// The usage message of each flag is shown as its description.
+func (f *FlagSet) GenZshCompletion(w io.Writer) error {
+	return genZshCompletion(w, f.completion([]string{progName(f.name)}))
+}
+
+// GenZshCompletion writes a zsh completion script for the command and
+// its subcommands to w.
+func (p *ParentCommand) GenZshCompletion(w io.Writer) error {
+	return genZshCompletion(w, p.completion([]string{progName(p.name)}))
+}
+
+// GenZshCompletion writes a zsh completion script for the command-line flags to w.
+func GenZshCompletion(w io.Writer) error {
+	return CommandLine.GenZshCompletion(w)
+}
@@ -0,0 +1,321 @@
+package flag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ConfigDecoder turns the content of a configuration file into a map.
+// Nested maps are flattened into dotted keys, so a table server holding
+// the key port sets the flag named server.port or server-port.
+type ConfigDecoder interface {
+	Decode(r io.Reader) (map[string]interface{}, error)
+}
+
+// The ConfigDecoderFunc type is an adapter to allow the use of ordinary
+// functions as configuration decoders.
+type ConfigDecoderFunc func(r io.Reader) (map[string]interface{}, error)
+
+// Decode calls d(r).
+func (d ConfigDecoderFunc) Decode(r io.Reader) (map[string]interface{}, error) {
+	return d(r)
+}
+
+// JSONDecoder decodes JSON configuration files.
+var JSONDecoder ConfigDecoder = ConfigDecoderFunc(decodeJSON)
+
+// YAMLDecoder decodes the commonly used subset of YAML: block mappings,
+// block and flow sequences of scalars, plain and quoted scalars, comments.
+var YAMLDecoder ConfigDecoder = ConfigDecoderFunc(decodeYAML)
+
+// TOMLDecoder decodes TOML configuration files, except arrays of tables
+// and multi-line strings.
+var TOMLDecoder ConfigDecoder = ConfigDecoderFunc(decodeTOML)
+
+// configDecoders maps a file extension to the decoder used by ParseFile.
+var configDecoders = map[string]ConfigDecoder{
+	".json": JSONDecoder,
+	".yaml": YAMLDecoder,
+	".yml":  YAMLDecoder,
+	".toml": TOMLDecoder,
+}
+
+// SetConfigDecoder registers the decoder used by ParseFile for files with
+// the extension ext, for example ".ini". It overrides the built-in decoders.
+func (f *FlagSet) SetConfigDecoder(ext string, d ConfigDecoder) *FlagSet {
+	if f.configDecoders == nil {
+		f.configDecoders = make(map[string]ConfigDecoder)
+	}
+	f.configDecoders[strings.ToLower(ext)] = d
+	return f
+}
+
+// SetConfigDecoder registers a configuration decoder for the command-line flags.
+func SetConfigDecoder(ext string, d ConfigDecoder) {
+	CommandLine.SetConfigDecoder(ext, d)
+}
+
+func (f *FlagSet) configDecoder(path string) ConfigDecoder {
+	ext := strings.ToLower(filepath.Ext(path))
+	if d, ok := f.configDecoders[ext]; ok {
+		return d
+	}
+	return configDecoders[ext]
+}
+
+func decodeJSON(r io.Reader) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	if err := d.Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// flattenConfig copies m into out, joining the keys of nested maps with dots.
+func flattenConfig(prefix string, m map[string]interface{}, out map[string]interface{}) {
+	for k, v := range m {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+
+		if sub, ok := v.(map[string]interface{}); ok {
+			flattenConfig(k, sub, out)
+			continue
+		}
+		out[k] = v
+	}
+}
+
+// isSliceValue reports whether v accumulates one element per call to Set.
+func isSliceValue(v Value) bool {
+	switch v.(type) {
+	case *stringSliceValue, *int64SliceValue, *boolSlice, *durationSliceValue:
+		return true
+	}
+	return false
+}
+
+// lookupConfigKey finds the flag that has key as one of its short/long names.
+// A dotted key also matches the flag whose name has dashes for the dots.
+func (f *FlagSet) lookupConfigKey(key string) *Flag {
+	for _, name := range []string{key, strings.Replace(key, ".", "-", -1)} {
+		if flag, ok := f.formal[name]; ok {
+			return flag
+		}
+
+		if flag, ok := f.shortLong[name]; ok {
+			return flag
+		}
+	}
+
+	return nil
+}
+
+// configStrings turns a decoded configuration value into the strings
+// passed to Value.Set.
+func configStrings(v interface{}) ([]string, error) {
+	switch x := v.(type) {
+	case string:
+		return []string{x}, nil
+	case bool:
+		return []string{strconv.FormatBool(x)}, nil
+	case json.Number:
+		return []string{x.String()}, nil
+	case float64:
+		return []string{strconv.FormatFloat(x, 'f', -1, 64)}, nil
+	case int64:
+		return []string{strconv.FormatInt(x, 10)}, nil
+	case int:
+		return []string{strconv.Itoa(x)}, nil
+	case []interface{}:
+		rv := make([]string, 0, len(x))
+		for _, e := range x {
+			if _, ok := e.([]interface{}); ok {
+				return nil, fmt.Errorf("nested arrays are not supported")
+			}
+
+			s, err := configStrings(e)
+			if err != nil {
+				return nil, err
+			}
+			rv = append(rv, s...)
+		}
+		return rv, nil
+	}
+
+	return nil, fmt.Errorf("unsupported value type %T", v)
+}
+
+// parseConfig sets the flags named by the keys of m. Flags that were already
+// set by a higher priority source, such as the command line, are left alone.
+func (f *FlagSet) parseConfig(source string, config map[string]interface{}) error {
+	m := make(map[string]interface{}, len(config))
+	flattenConfig("", config, m)
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	flags := make([]*Flag, len(keys))
+	for i, key := range keys {
+		flag := f.lookupConfigKey(key)
+		if flag == nil {
+			return f.failf("%s: key %q: flag provided but not defined", source, key)
+		}
+
+		if !f.isSet(flag) {
+			flags[i] = flag
+		}
+	}
+
+	for i, key := range keys {
+		flag := flags[i]
+		if flag == nil || m[key] == nil {
+			continue
+		}
+
+		values, err := configStrings(m[key])
+		if err != nil {
+			return f.failf("%s: key %q: %v", source, key, err)
+		}
+
+		if _, ok := m[key].([]interface{}); ok && !isSliceValue(flag.Value) {
+			return f.failf("%s: key %q: array given for single value flag -%s", source, key, flag.Name)
+		}
+
+		// an array is set as a whole or not at all
+		restore := snapshot(flag.Value)
+		for _, value := range values {
+			if err := flag.set(value); err != nil {
+				restore()
+				return f.failf("%s: key %q: invalid value %q: %v", source, key, value, err)
+			}
+		}
+
+		f.markSet(flag)
+	}
+
+	return nil
+}
+
+func (f *FlagSet) parseReader(source string, r io.Reader, d ConfigDecoder) error {
+	m, err := d.Decode(r)
+	if err != nil {
+		return f.failf("%s: %v", source, err)
+	}
+
+	return f.parseConfig(source, m)
+}
+
+// ParseJSON fills the flags from a JSON object whose keys are the short or
+// long names of the flags. Arrays are accepted by slice flags, Set is called
+// once per element. Flags that are already set, for instance on the command
+// line by a previous call to Parse, keep their value.
+func (f *FlagSet) ParseJSON(r io.Reader) error {
+	return f.handleError(f.parseReader("json", r, JSONDecoder))
+}
+
+// ParseConfig fills the flags from r decoded by d. See ParseJSON for details.
+func (f *FlagSet) ParseConfig(r io.Reader, d ConfigDecoder) error {
+	return f.handleError(f.parseReader("config", r, d))
+}
+
+func (f *FlagSet) parseFile(path string) error {
+	return f.parseFileInclude(path, nil)
+}
+
+// parseFileInclude loads path and then the files listed by its include key.
+// Values of the including file take precedence over the included ones.
+// stack holds the files being loaded and is used to detect include cycles.
+func (f *FlagSet) parseFileInclude(path string, stack []string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return f.failf("%v", err)
+	}
+
+	for i, p := range stack {
+		if p == abs {
+			return f.failf("%s: include cycle: %s", path, strings.Join(append(stack[i:], abs), " -> "))
+		}
+	}
+	stack = append(stack, abs)
+
+	d := f.configDecoder(path)
+	if d == nil {
+		return f.failf("%s: unsupported configuration file type", path)
+	}
+
+	fd, err := os.Open(path)
+	if err != nil {
+		return f.failf("%v", err)
+	}
+
+	m, err := d.Decode(fd)
+	fd.Close()
+	if err != nil {
+		return f.failf("%s: %v", path, err)
+	}
+
+	var include []string
+	if v, ok := m["include"]; ok && f.lookupConfigKey("include") == nil {
+		if include, err = configStrings(v); err != nil {
+			return f.failf("%s: key %q: %v", path, "include", err)
+		}
+		delete(m, "include")
+	}
+
+	if err = f.parseConfig(path, m); err != nil {
+		return err
+	}
+
+	for _, name := range include {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(filepath.Dir(path), name)
+		}
+
+		if err = f.parseFileInclude(name, stack); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ParseFile fills the flags from the configuration file path. The decoder
+// is chosen by the file extension: .json, .yaml, .yml and .toml are built
+// in, others can be added with SetConfigDecoder. See ParseJSON for details.
+//
+// The include key, a path or a list of paths relative to the file, loads
+// other configuration files. Values of the including file win.
+func (f *FlagSet) ParseFile(path string) error {
+	return f.handleError(f.parseFile(path))
+}
+
+// ParseFile fills the command-line flags from the configuration file path.
+func ParseFile(path string) error {
+	return CommandLine.ParseFile(path)
+}
+
+// ConfigFlag registers a flag, for instance "c, config", naming a
+// configuration file. Parse loads the file after the command line and
+// the environment, so that the values they give take precedence.
+func (f *FlagSet) ConfigFlag(name string) *FlagSet {
+	f.configPath = f.String(name, "", "load options from the configuration `file`")
+	return f
+}
+
+// ConfigFlag registers a configuration file flag for the command-line flags.
+func ConfigFlag(name string) {
+	CommandLine.ConfigFlag(name)
+}
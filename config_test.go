@@ -0,0 +1,205 @@
+package flag
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, pattern, content string) string {
+	fd, err := ioutil.TempFile("", pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+
+	if _, err = fd.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	return fd.Name()
+}
+
+func TestParseJSON(t *testing.T) {
+	fs := NewFlagSet("config", ContinueOnError)
+	port := fs.Opt("p, port", "listen port").NewInt(80)
+	host := fs.Opt("host", "server host").NewString("localhost")
+	debug := fs.Opt("d, debug", "debug mode").NewBool(false)
+	header := fs.Opt("H, header", "http header").NewStringSlice([]string{})
+	ids := fs.Opt("id", "ids").NewInt64Slice([]int64{})
+	bs := fs.Opt("b", "bools").NewBoolSlice([]bool{})
+	ds := fs.DurationSlice("ds", []time.Duration{}, "durations")
+
+	fs.Parse([]string{"--host", "example.com"})
+	err := fs.ParseJSON(strings.NewReader(`{
+		"port": 8080,
+		"host": "ignored.com",
+		"d": true,
+		"header": ["a:1", "b:2"],
+		"id": [1, 2, 3],
+		"b": [true, false],
+		"ds": ["1s", "2m"]
+	}`))
+
+	if err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	if *port != 8080 {
+		t.Errorf("port got %d want 8080\n", *port)
+	}
+
+	if *host != "example.com" {
+		t.Errorf("host got %s want example.com\n", *host)
+	}
+
+	if !*debug {
+		t.Errorf("debug got false want true\n")
+	}
+
+	if !reflect.DeepEqual(*header, []string{"a:1", "b:2"}) {
+		t.Errorf("header got %v\n", *header)
+	}
+
+	if !reflect.DeepEqual(*ids, []int64{1, 2, 3}) {
+		t.Errorf("id got %v\n", *ids)
+	}
+
+	if !reflect.DeepEqual(*bs, []bool{true, false}) {
+		t.Errorf("b got %v\n", *bs)
+	}
+
+	if !reflect.DeepEqual(*ds, []time.Duration{time.Second, 2 * time.Minute}) {
+		t.Errorf("ds got %v\n", *ds)
+	}
+}
+
+func TestParseFileError(t *testing.T) {
+	path := writeConfig(t, "flag*.json", `{"port": "http"}`)
+	defer os.Remove(path)
+
+	var buf bytes.Buffer
+	fs := NewFlagSet("config", ContinueOnError)
+	fs.SetOutput(&buf)
+	fs.Opt("p, port", "listen port").NewInt(80)
+
+	err := fs.ParseFile(path)
+	if err == nil || !strings.Contains(err.Error(), path) || !strings.Contains(err.Error(), `"port"`) {
+		t.Errorf("got %v want error naming file and key\n", err)
+	}
+
+	err = fs.ParseJSON(strings.NewReader(`{"unknown": 1}`))
+	if err == nil || !strings.Contains(err.Error(), `"unknown"`) {
+		t.Errorf("got %v want error naming key\n", err)
+	}
+}
+
+func TestSetConfigDecoder(t *testing.T) {
+	path := writeConfig(t, "flag*.conf", "")
+	defer os.Remove(path)
+
+	fs := NewFlagSet("config", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	port := fs.Opt("p, port", "listen port").NewInt(80)
+	if err := fs.ParseFile(path); err == nil {
+		t.Errorf("unknown extension should fail\n")
+	}
+
+	fs.SetConfigDecoder(".conf", ConfigDecoderFunc(func(r io.Reader) (map[string]interface{}, error) {
+		return map[string]interface{}{"port": 8080}, nil
+	}))
+
+	if err := fs.ParseFile(path); err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	if *port != 8080 {
+		t.Errorf("port got %d want 8080\n", *port)
+	}
+}
+
+func TestConfigFlag(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "main.json"), []byte(`{"include": "base.yaml", "port": 8080}`), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "base.yaml"), []byte("port: 1\nhost: example.com\nheader: [a, b]\n"), 0644)
+
+	fs := NewFlagSet("config", ContinueOnError)
+	fs.ConfigFlag("c, config")
+	port := fs.Opt("p, port", "listen port").NewInt(80)
+	host := fs.Opt("host", "server host").NewString("localhost")
+	header := fs.Opt("H, header", "http header").NewStringSlice([]string{})
+
+	err = fs.Parse([]string{"-H", "c", "-c", filepath.Join(dir, "main.json")})
+	if err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	if *port != 8080 {
+		t.Errorf("port got %d want 8080\n", *port)
+	}
+
+	if *host != "example.com" {
+		t.Errorf("host got %s want example.com\n", *host)
+	}
+
+	if !reflect.DeepEqual(*header, []string{"c"}) {
+		t.Errorf("header got %v want [c]\n", *header)
+	}
+}
+
+func TestConfigIncludeCycle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"include": ["b.json"]}`), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"include": "a.json"}`), 0644)
+
+	fs := NewFlagSet("config", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	err = fs.ParseFile(filepath.Join(dir, "a.json"))
+	if err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("got %v want include cycle error\n", err)
+	}
+}
+
+func TestParseJSONMatchVar(t *testing.T) {
+	fs := NewFlagSet("config", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	header := true
+	fs.Opt("no-header", "hide the header").MatchVar(&header, false)
+
+	if err := fs.ParseJSON(strings.NewReader(`{"no-header": true}`)); err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	if header {
+		t.Errorf("header got true want false\n")
+	}
+}
+
+func TestParseJSONArrayError(t *testing.T) {
+	fs := NewFlagSet("config", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	c := fs.Opt("c", "columns").Enum("id", "name").NewStringSlice([]string{})
+
+	if err := fs.ParseJSON(strings.NewReader(`{"c": ["id", "age"]}`)); err == nil {
+		t.Fatalf("got nil want error\n")
+	}
+
+	if len(*c) != 0 {
+		t.Errorf("a rejected array should not be set, got %v\n", *c)
+	}
+}
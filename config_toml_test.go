@@ -0,0 +1,78 @@
+package flag
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeTOML(t *testing.T) {
+	m, err := decodeTOML(strings.NewReader(`
+# server options
+title = "TOML \"example\""
+path = 'C:\Users'
+count = 1_000
+dt = 1979-05-27 07:32:00
+
+[server]
+port = 8080 # comment
+hosts = [
+  "a", # first
+  "b",
+]
+
+[server.tls]
+enable = true
+
+[log]
+level.name = "debug"
+point = { x = 1, y = 2 }
+`))
+	if err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	need := map[string]interface{}{
+		"title": `TOML "example"`,
+		"path":  `C:\Users`,
+		"count": "1000",
+		"dt":    "1979-05-27 07:32:00",
+		"server": map[string]interface{}{
+			"port":  "8080",
+			"hosts": []interface{}{"a", "b"},
+			"tls":   map[string]interface{}{"enable": "true"},
+		},
+		"log": map[string]interface{}{
+			"level": map[string]interface{}{"name": "debug"},
+			"point": map[string]interface{}{"x": "1", "y": "2"},
+		},
+	}
+
+	if !reflect.DeepEqual(m, need) {
+		t.Errorf("got %#v\nwant %#v\n", m, need)
+	}
+}
+
+func TestDecodeTOMLError(t *testing.T) {
+	for _, s := range []string{"a = 1\na = 2\n", "a = 1 b\n", "[[servers]]\n", "a = \"x\n"} {
+		if _, err := decodeTOML(strings.NewReader(s)); err == nil {
+			t.Errorf("%q should fail\n", s)
+		}
+	}
+}
+
+func TestParseFileTOML(t *testing.T) {
+	path := writeConfig(t, "flag*.toml", "[server]\nport = 8080\n")
+	defer os.Remove(path)
+
+	fs := NewFlagSet("config", ContinueOnError)
+	port := fs.Opt("p, server.port", "listen port").NewInt(80)
+	if err := fs.ParseFile(path); err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	if *port != 8080 {
+		t.Errorf("port got %d want 8080\n", *port)
+	}
+}
@@ -0,0 +1,283 @@
+package flag
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// yamlLine is a non-empty line of a YAML document without its comment.
+type yamlLine struct {
+	indent int
+	text   string
+	no     int
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func (p *yamlParser) errorf(format string, a ...interface{}) error {
+	no := 0
+	if p.pos < len(p.lines) {
+		no = p.lines[p.pos].no
+	} else if len(p.lines) > 0 {
+		no = p.lines[len(p.lines)-1].no
+	}
+	return fmt.Errorf("yaml: line %d: %s", no, fmt.Sprintf(format, a...))
+}
+
+// stripYAMLComment removes a trailing comment, ignoring '#' inside quotes.
+func stripYAMLComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func decodeYAML(r io.Reader) (map[string]interface{}, error) {
+	p := &yamlParser{}
+
+	scanner := bufio.NewScanner(r)
+	for no := 1; scanner.Scan(); no++ {
+		line := strings.TrimRight(stripYAMLComment(scanner.Text()), " \t\r")
+		text := strings.TrimLeft(line, " ")
+		if text == "" || text == "---" {
+			continue
+		}
+
+		if text == "..." {
+			break
+		}
+
+		if text[0] == '\t' {
+			return nil, fmt.Errorf("yaml: line %d: found tab character in indentation", no)
+		}
+
+		p.lines = append(p.lines, yamlLine{indent: len(line) - len(text), text: text, no: no})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]interface{})
+	if len(p.lines) == 0 {
+		return m, nil
+	}
+
+	if err := p.parseMapping(p.lines[0].indent, m); err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.lines) {
+		return nil, p.errorf("unexpected indentation")
+	}
+
+	return m, nil
+}
+
+// splitYAMLKey splits "key: value" at the first colon outside quotes that is
+// followed by a space or the end of the line.
+func splitYAMLKey(s string) (key string, value string, ok bool) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && i == 0:
+			quote = c
+		case c == ':' && (i+1 == len(s) || s[i+1] == ' '):
+			key, err := parseYAMLScalar(strings.TrimSpace(s[:i]))
+			if err != nil {
+				return "", "", false
+			}
+			k, isString := key.(string)
+			if !isString {
+				return "", "", false
+			}
+			return k, strings.TrimSpace(s[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+func (p *yamlParser) parseMapping(indent int, m map[string]interface{}) error {
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			return nil
+		}
+
+		if line.indent > indent {
+			return p.errorf("unexpected indentation")
+		}
+
+		key, value, ok := splitYAMLKey(line.text)
+		if !ok {
+			return p.errorf("expected \"key: value\", found %q", line.text)
+		}
+
+		if _, alreadythere := m[key]; alreadythere {
+			return p.errorf("duplicate key %q", key)
+		}
+
+		p.pos++
+		if value != "" {
+			v, err := parseYAMLScalar(value)
+			if err != nil {
+				return p.errorf("%v", err)
+			}
+			m[key] = v
+			continue
+		}
+
+		// The value is a nested block, or null when there is none.
+		m[key] = nil
+		if p.pos == len(p.lines) {
+			continue
+		}
+
+		next := p.lines[p.pos]
+		switch {
+		case isYAMLSequence(next.text) && next.indent >= indent:
+			v, err := p.parseSequence(next.indent)
+			if err != nil {
+				return err
+			}
+			m[key] = v
+		case next.indent > indent:
+			sub := make(map[string]interface{})
+			if err := p.parseMapping(next.indent, sub); err != nil {
+				return err
+			}
+			m[key] = sub
+		}
+	}
+
+	return nil
+}
+
+func isYAMLSequence(s string) bool {
+	return s == "-" || strings.HasPrefix(s, "- ")
+}
+
+func (p *yamlParser) parseSequence(indent int) ([]interface{}, error) {
+	list := make([]interface{}, 0, 4)
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent || !isYAMLSequence(line.text) {
+			break
+		}
+
+		value := strings.TrimSpace(line.text[1:])
+		if _, _, ok := splitYAMLKey(value); ok || value == "" {
+			return nil, p.errorf("only sequences of scalars are supported")
+		}
+
+		v, err := parseYAMLScalar(value)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		list = append(list, v)
+		p.pos++
+	}
+	return list, nil
+}
+
+// parseYAMLScalar parses a plain or quoted scalar or a flow sequence.
+// Scalars are returned as strings; null is returned as nil.
+func parseYAMLScalar(s string) (interface{}, error) {
+	switch {
+	case s == "" || s == "~" || s == "null" || s == "Null" || s == "NULL":
+		return nil, nil
+	case s[0] == '"':
+		if len(s) < 2 || s[len(s)-1] != '"' {
+			return nil, fmt.Errorf("unterminated string %s", s)
+		}
+		return strconv.Unquote(s)
+	case s[0] == '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return nil, fmt.Errorf("unterminated string %s", s)
+		}
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	case s[0] == '[':
+		if s[len(s)-1] != ']' {
+			return nil, fmt.Errorf("unterminated flow sequence %s", s)
+		}
+		return parseYAMLFlowSequence(s[1 : len(s)-1])
+	case s[0] == '{', s[0] == '&', s[0] == '*', s[0] == '|', s[0] == '>':
+		return nil, fmt.Errorf("unsupported yaml syntax %s", s)
+	}
+
+	return s, nil
+}
+
+func parseYAMLFlowSequence(s string) ([]interface{}, error) {
+	list := make([]interface{}, 0, 4)
+
+	var quote byte
+	start := 0
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) {
+			c := s[i]
+			if quote != 0 {
+				if c == quote {
+					quote = 0
+				} else if c == '\\' && quote == '"' {
+					i++
+				}
+				continue
+			}
+
+			if c == '"' || c == '\'' {
+				quote = c
+				continue
+			}
+
+			if c == '[' {
+				return nil, fmt.Errorf("nested arrays are not supported")
+			}
+
+			if c != ',' {
+				continue
+			}
+		}
+
+		item := strings.TrimSpace(s[start:i])
+		start = i + 1
+		if item == "" {
+			if i == len(s) {
+				break
+			}
+			return nil, fmt.Errorf("empty item in flow sequence")
+		}
+
+		v, err := parseYAMLScalar(item)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+
+	return list, nil
+}
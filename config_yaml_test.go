@@ -0,0 +1,85 @@
+package flag
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeYAML(t *testing.T) {
+	_, err := decodeYAML(strings.NewReader(`
+# server options
+---
+server:
+  port: 8080 # comment
+  host: "example.com"
+debug: true
+name: 'it''s'
+url: http://example.com/#top
+header:
+  - "appkey:123"
+  - User-Agent: main
+`))
+	if err == nil {
+		t.Errorf("sequence of mappings should be rejected\n")
+	}
+
+	m, err := decodeYAML(strings.NewReader(`
+server:
+  port: 8080 # comment
+  host: "example.com"
+debug: true
+name: 'it''s'
+url: http://example.com/#top
+empty:
+header:
+- appkey:123
+- "a # b"
+ids: [1, 2, 3]
+`))
+	if err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	need := map[string]interface{}{
+		"server": map[string]interface{}{"port": "8080", "host": "example.com"},
+		"debug":  "true",
+		"name":   "it's",
+		"url":    "http://example.com/#top",
+		"empty":  nil,
+		"header": []interface{}{"appkey:123", "a # b"},
+		"ids":    []interface{}{"1", "2", "3"},
+	}
+
+	if !reflect.DeepEqual(m, need) {
+		t.Errorf("got %#v\nwant %#v\n", m, need)
+	}
+}
+
+func TestDecodeYAMLError(t *testing.T) {
+	_, err := decodeYAML(strings.NewReader("a: 1\n  b: 2\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("got %v want error at line 2\n", err)
+	}
+}
+
+func TestParseFileYAML(t *testing.T) {
+	path := writeConfig(t, "flag*.yaml", "server:\n  port: 8080\nheader: [a, b]\n")
+	defer os.Remove(path)
+
+	fs := NewFlagSet("config", ContinueOnError)
+	port := fs.Opt("server-port", "listen port").NewInt(80)
+	header := fs.Opt("H, header", "http header").NewStringSlice([]string{})
+	if err := fs.ParseFile(path); err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	if *port != 8080 {
+		t.Errorf("port got %d want 8080\n", *port)
+	}
+
+	if !reflect.DeepEqual(*header, []string{"a", "b"}) {
+		t.Errorf("header got %v\n", *header)
+	}
+}
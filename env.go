@@ -0,0 +1,123 @@
+package flag
+
+import (
+	"os"
+	"reflect"
+	"strings"
+)
+
+// SetEnvPrefix makes every flag of the set readable from the environment
+// variable PREFIX_NAME, where NAME is the longest name of the flag in upper
+// case with dashes turned into underscores. A name given by Flag.Env wins.
+func (f *FlagSet) SetEnvPrefix(prefix string) *FlagSet {
+	f.envPrefix = prefix
+	return f
+}
+
+// SetEnvPrefix sets the environment variable prefix of the command-line flags.
+func SetEnvPrefix(prefix string) {
+	CommandLine.SetEnvPrefix(prefix)
+}
+
+// longestName returns the longest short/long name of flag.
+func longestName(flag *Flag) string {
+	longest := ""
+	for _, name := range flagNames(flag) {
+		if len(name) > len(longest) {
+			longest = name
+		}
+	}
+	return longest
+}
+
+// envName returns the environment variable bound to flag, or "" if none.
+func (f *FlagSet) envName(flag *Flag) string {
+	if flag.env != "" || f.envPrefix == "" {
+		return flag.env
+	}
+
+	if isGeneratedFlag(flag) {
+		return ""
+	}
+
+	name := longestName(flag)
+	if name == "" {
+		return ""
+	}
+
+	name = strings.ToUpper(strings.Replace(name, "-", "_", -1))
+	return f.envPrefix + "_" + name
+}
+
+// sameValue reports whether a and b are the storage of the same option.
+// All names of an option (short, long, regex) share one Value.
+func sameValue(a, b Value) bool {
+	if a == nil || b == nil {
+		return false
+	}
+
+	if !reflect.TypeOf(a).Comparable() || !reflect.TypeOf(b).Comparable() {
+		return false
+	}
+
+	return a == b
+}
+
+// isSet reports whether flag has been set by any source, under any of its names.
+func (f *FlagSet) isSet(flag *Flag) bool {
+	for _, set := range append([]*FlagSet{f}, f.persistent...) {
+		for _, v := range set.actual {
+			if sameValue(v.Value, flag.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// markSet records flag as set so that lower priority sources leave it alone.
+func (f *FlagSet) markSet(flag *Flag) {
+	if f.actual == nil {
+		f.actual = make(map[string]*Flag)
+	}
+	f.actual[flag.Name] = flag
+}
+
+// parseEnv fills the flags that were not given on the command line
+// from their environment variables.
+func (f *FlagSet) parseEnv() error {
+	if err := f.parseEnvFlags(f, sortFlags(f.formal)); err != nil {
+		return err
+	}
+
+	var err error
+	visitGlobal(f.persistent, f.formal, func(set *FlagSet, flag *Flag) {
+		if err == nil {
+			err = f.parseEnvFlags(set, []*Flag{flag})
+		}
+	})
+	return err
+}
+
+// parseEnvFlags fills flags, defined in set, from the environment.
+func (f *FlagSet) parseEnvFlags(set *FlagSet, flags []*Flag) error {
+	for _, flag := range flags {
+		name := set.envName(flag)
+		if name == "" || f.isSet(flag) {
+			continue
+		}
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		if err := flag.set(value); err != nil {
+			return f.failf("invalid value %q for env $%s (flag -%s): %v", value, name, flag.Name, err)
+		}
+
+		f.markSet(flag)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package flag
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEnv(t *testing.T) {
+	os.Setenv("FLAG_TEST_PORT", "8080")
+	defer os.Unsetenv("FLAG_TEST_PORT")
+
+	fs := NewFlagSet("env", ContinueOnError)
+	port := fs.Opt("p, port", "listen port").Env("FLAG_TEST_PORT").NewInt(80)
+	fs.Parse([]string{})
+	if *port != 8080 {
+		t.Errorf("port got %d want 8080\n", *port)
+	}
+
+	fs = NewFlagSet("env", ContinueOnError)
+	port = fs.Opt("p, port", "listen port").Env("FLAG_TEST_PORT").NewInt(80)
+	fs.Parse([]string{"-p", "9090"})
+	if *port != 9090 {
+		t.Errorf("port got %d want 9090\n", *port)
+	}
+
+	fs = NewFlagSet("env", ContinueOnError)
+	port = fs.Opt("p, port", "listen port").Env("FLAG_TEST_UNSET").NewInt(80)
+	fs.Parse([]string{})
+	if *port != 80 {
+		t.Errorf("port got %d want 80\n", *port)
+	}
+}
+
+func TestEnvInvalid(t *testing.T) {
+	os.Setenv("FLAG_TEST_PORT", "http")
+	defer os.Unsetenv("FLAG_TEST_PORT")
+
+	var buf bytes.Buffer
+	fs := NewFlagSet("env", ContinueOnError)
+	fs.SetOutput(&buf)
+	fs.Opt("p, port", "listen port").Env("FLAG_TEST_PORT").NewInt(80)
+	err := fs.Parse([]string{})
+	if err == nil || !strings.Contains(err.Error(), "$FLAG_TEST_PORT") {
+		t.Errorf("got %v want error about $FLAG_TEST_PORT\n", err)
+	}
+}
+
+func TestEnvStruct(t *testing.T) {
+	os.Setenv("FLAG_TEST_HOST", "example.com")
+	defer os.Unsetenv("FLAG_TEST_HOST")
+
+	type option struct {
+		Host string `opt:"host" env:"FLAG_TEST_HOST" defValue:"localhost" usage:"server host"`
+	}
+
+	o := option{}
+	fs := NewFlagSet("env", ContinueOnError)
+	fs.ParseStruct([]string{}, &o)
+	if o.Host != "example.com" {
+		t.Errorf("host got %s want example.com\n", o.Host)
+	}
+}
+
+func TestEnvPrintDefaults(t *testing.T) {
+	var buf bytes.Buffer
+	fs := NewFlagSet("env", ContinueOnError)
+	fs.SetOutput(&buf)
+	fs.Opt("p, port", "listen port").Env("APP_PORT").NewInt(80)
+	fs.PrintDefaults()
+	if !strings.Contains(buf.String(), "(env $APP_PORT)") {
+		t.Errorf("got %q want (env $APP_PORT)\n", buf.String())
+	}
+}
+
+func TestEnvPrefix(t *testing.T) {
+	os.Setenv("MYTOOL_MAX_THREADS", "8")
+	os.Setenv("MYTOOL_LINES", "3")
+	os.Setenv("MYTOOL_NAME", "gopher")
+	os.Setenv("MYTOOL_VERBOSE", "true")
+	defer func() {
+		os.Unsetenv("MYTOOL_MAX_THREADS")
+		os.Unsetenv("MYTOOL_LINES")
+		os.Unsetenv("MYTOOL_NAME")
+		os.Unsetenv("MYTOOL_VERBOSE")
+	}()
+
+	type option struct {
+		Verbose bool `opt:"v, verbose" usage:"verbose output"`
+	}
+
+	fs := NewFlagSet("mytool", ContinueOnError)
+	fs.SetEnvPrefix("MYTOOL")
+	threads := fs.Opt("m, max-threads", "max threads").NewInt(1)
+	lines := fs.OptOpt(Flag{Short: []string{"n"}, Long: []string{"lines"}, Usage: "lines"}).NewInt(10)
+	name := fs.String("name", "", "user name")
+	o := option{}
+	fs.ParseStruct([]string{"-m", "2"}, &o)
+
+	if *threads != 2 {
+		t.Errorf("threads got %d want 2\n", *threads)
+	}
+
+	if *lines != 3 {
+		t.Errorf("lines got %d want 3\n", *lines)
+	}
+
+	if *name != "gopher" {
+		t.Errorf("name got %s want gopher\n", *name)
+	}
+
+	if !o.Verbose {
+		t.Errorf("verbose got false want true\n")
+	}
+}
+
+func TestEnvMatchVar(t *testing.T) {
+	for _, test := range []struct {
+		value  string
+		header bool
+	}{
+		{"1", false},
+		{"false", true},
+	} {
+		os.Setenv("FLAG_TEST_NO_HEADER", test.value)
+
+		fs := NewFlagSet("env", ContinueOnError)
+		fs.SetOutput(&bytes.Buffer{})
+		fs.SetEnvPrefix("FLAG_TEST")
+		header := true
+		fs.Opt("no-header", "hide the header").MatchVar(&header, false)
+
+		if err := fs.Parse(nil); err != nil {
+			t.Fatalf("got %v want nil\n", err)
+		}
+
+		if header != test.header {
+			t.Errorf("FLAG_TEST_NO_HEADER=%s: header got %t want %t\n", test.value, header, test.header)
+		}
+	}
+
+	os.Setenv("FLAG_TEST_NO_HEADER", "x")
+	defer os.Unsetenv("FLAG_TEST_NO_HEADER")
+
+	fs := NewFlagSet("env", ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	fs.SetEnvPrefix("FLAG_TEST")
+	fs.Opt("no-header", "hide the header").MatchVar(new(bool), false)
+	if err := fs.Parse(nil); err == nil {
+		t.Errorf("got nil want invalid value error\n")
+	}
+}
@@ -0,0 +1,298 @@
+package flag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// UnknownFlagError is returned by Parse for a flag that is not defined.
+// Suggestions holds the defined names closest to Name, best first.
+type UnknownFlagError struct {
+	Name        string
+	Suggestions []string
+}
+
+func (e *UnknownFlagError) Error() string {
+	return "flag provided but not defined: -" + e.Name + didYouMean("-", e.Suggestions)
+}
+
+// UnknownSubcommandError is returned by ParentCommand.Parse for a subcommand
+// that is not defined. Suggestions holds the subcommand names closest to
+// Name, best first.
+type UnknownSubcommandError struct {
+	Name        string
+	Suggestions []string
+}
+
+func (e *UnknownSubcommandError) Error() string {
+	return "subcommand provided but not defined: -" + e.Name + didYouMean("", e.Suggestions)
+}
+
+// AmbiguousFlagError is returned by Parse, when abbreviations are allowed,
+// for a prefix shared by several flags.
+type AmbiguousFlagError struct {
+	Name       string
+	Candidates []string
+}
+
+func (e *AmbiguousFlagError) Error() string {
+	return "ambiguous flag -" + e.Name + ": could be -" + strings.Join(e.Candidates, ", -")
+}
+
+// AmbiguousSubcommandError is returned by ParentCommand.Parse, when
+// abbreviations are allowed, for a prefix shared by several subcommands.
+type AmbiguousSubcommandError struct {
+	Name       string
+	Candidates []string
+}
+
+func (e *AmbiguousSubcommandError) Error() string {
+	return "ambiguous subcommand " + e.Name + ": could be " + strings.Join(e.Candidates, ", ")
+}
+
+// MissingArgumentError is returned by Parse for a flag given without its
+// value at the end of the command line.
+type MissingArgumentError struct {
+	Flag string // name of the flag as given
+}
+
+func (e *MissingArgumentError) Error() string {
+	return "flag needs an argument: -" + e.Flag
+}
+
+// InvalidValueError is returned by Parse when the Set method of a flag
+// rejects the value given on the command line.
+type InvalidValueError struct {
+	Flag  string // name of the flag as given
+	Value string
+	Err   error // error returned by Set
+
+	boolean bool // Flag is a boolean flag; Value is empty if none was given
+}
+
+func (e *InvalidValueError) Error() string {
+	if e.boolean {
+		if e.Value == "" {
+			return fmt.Sprintf("invalid boolean flag %s: %v", e.Flag, e.Err)
+		}
+		return fmt.Sprintf("invalid boolean value %q for -%s: %v", e.Value, e.Flag, e.Err)
+	}
+	return fmt.Sprintf("invalid value %q for flag -%s: %v", e.Value, e.Flag, e.Err)
+}
+
+func (e *InvalidValueError) Unwrap() error { return e.Err }
+
+// BadSyntaxError is returned by Parse for an argument that starts like a
+// flag but is not one, such as ---x or -=x.
+type BadSyntaxError struct {
+	Arg string
+}
+
+func (e *BadSyntaxError) Error() string {
+	return "bad flag syntax: " + e.Arg
+}
+
+// MissingRequiredError is returned by Parse when flags marked Required
+// were not set. Flags holds their longest names.
+type MissingRequiredError struct {
+	Flags []string
+}
+
+func (e *MissingRequiredError) Error() string {
+	msg := "missing required flag: -"
+	if len(e.Flags) > 1 {
+		msg = "missing required flags: -"
+	}
+	return msg + strings.Join(e.Flags, ", -")
+}
+
+// MutuallyExclusiveError is returned by Parse when flags of a
+// MutuallyExclusive group are used together. Flags holds the ones set.
+type MutuallyExclusiveError struct {
+	Flags []string
+}
+
+func (e *MutuallyExclusiveError) Error() string {
+	return "flags cannot be used together: -" + strings.Join(e.Flags, ", -")
+}
+
+// OneRequiredError is returned by Parse when no flag of a OneRequired
+// group is set.
+type OneRequiredError struct {
+	Flags []string
+}
+
+func (e *OneRequiredError) Error() string {
+	return "one of the flags is required: -" + strings.Join(e.Flags, ", -")
+}
+
+// RequiredTogetherError is returned by Parse when only some flags of a
+// RequiredTogether group are set. Missing holds the others.
+type RequiredTogetherError struct {
+	Flags   []string
+	Missing []string
+}
+
+func (e *RequiredTogetherError) Error() string {
+	return fmt.Sprintf("flags must be used together: -%s (missing -%s)",
+		strings.Join(e.Flags, ", -"), strings.Join(e.Missing, ", -"))
+}
+
+// ResponseFileError is returned by Parse, when response files are enabled,
+// for a problem found at line Line of the response file File. File is
+// empty when the response file named on the command line cannot be read;
+// Err, an *os.PathError, names it then.
+type ResponseFileError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e *ResponseFileError) Error() string {
+	if e.File == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *ResponseFileError) Unwrap() error { return e.Err }
+
+// ArgError is a problem found by ParseAll in the argument at position Index
+// of the argument list. Index is -1 for errors of the environment and the
+// configuration file.
+type ArgError struct {
+	Index int
+	Arg   string
+	Err   error
+}
+
+func (e *ArgError) Error() string {
+	if e.Index < 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("argument %d (%s): %v", e.Index+1, e.Arg, e.Err)
+}
+
+func (e *ArgError) Unwrap() error { return e.Err }
+
+// ParseErrors is the error returned by ParseAll, one entry per problem.
+type ParseErrors []*ArgError
+
+func (e ParseErrors) Error() string {
+	msgs := make([]string, len(e))
+	for k, err := range e {
+		msgs[k] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// failedFlags returns the longest names of the flags of f that got an
+// invalid or missing value on the command line.
+func (e ParseErrors) failedFlags(f *FlagSet) map[string]bool {
+	failed := make(map[string]bool)
+	for _, err := range e {
+		name := ""
+		switch err := err.Err.(type) {
+		case *InvalidValueError:
+			name = err.Flag
+		case *MissingArgumentError:
+			name = err.Flag
+		default:
+			continue
+		}
+
+		if flag := f.lookupInherited(name); flag != nil {
+			failed[longestName(flag)] = true
+		}
+	}
+	return failed
+}
+
+func didYouMean(prefix string, suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	return " (did you mean " + prefix + strings.Join(suggestions, " or "+prefix) + "?)"
+}
+
+// maxSuggestDistance is the largest edit distance of a suggested name.
+const maxSuggestDistance = 2
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// suggest returns the candidates close to name by edit distance, closest
+// first. A candidate is never suggested when it takes as many edits as
+// name has letters, so single letters get no suggestions.
+func suggest(name string, candidates []string) []string {
+	distance := make(map[string]int)
+	for _, c := range candidates {
+		if _, ok := distance[c]; ok || c == name || c == "" {
+			continue
+		}
+
+		d := editDistance(name, c)
+		if d <= maxSuggestDistance && d < len(name) {
+			distance[c] = d
+		}
+	}
+
+	suggestions := make([]string, 0, len(distance))
+	for c := range distance {
+		suggestions = append(suggestions, c)
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if distance[a] != distance[b] {
+			return distance[a] < distance[b]
+		}
+		return a < b
+	})
+	return suggestions
+}
+
+// flagSuggestions returns the names of the flags of f close to name.
+func (f *FlagSet) flagSuggestions(name string) []string {
+	var names []string
+	for _, flag := range append(sortFlags(f.formal), f.globalFlags()...) {
+		names = append(names, flagNames(flag)...)
+	}
+	return suggest(name, names)
+}
+
+// subCommandSuggestions returns the names of the subcommands of p close to name.
+func (p *ParentCommand) subCommandSuggestions(name string) []string {
+	names := make([]string, 0, len(p.subCommand2))
+	for n := range p.subCommand2 {
+		names = append(names, n)
+	}
+	return suggest(name, names)
+}
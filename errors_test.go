@@ -0,0 +1,196 @@
+package flag
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"header", "header", 0},
+		{"heder", "header", 1},
+		{"hedaer", "header", 2},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	}
+
+	for _, test := range tests {
+		if got := editDistance(test.a, test.b); got != test.want {
+			t.Errorf("editDistance(%q, %q) got %d want %d\n", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestUnknownFlagSuggestions(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Opt("H, header", "http header").NewStringSlice([]string{})
+	fs.Opt("reader", "reader").NewString("")
+	fs.Opt("x", "x").NewBool(false)
+
+	err := fs.Parse([]string{"--heder", "a"})
+	e, ok := err.(*UnknownFlagError)
+	if !ok {
+		t.Fatalf("got %T want *UnknownFlagError\n", err)
+	}
+
+	if e.Name != "heder" || !reflect.DeepEqual(e.Suggestions, []string{"header", "reader"}) {
+		t.Errorf("got %+v\n", e)
+	}
+
+	want := "flag provided but not defined: -heder (did you mean -header or -reader?)"
+	if err.Error() != want {
+		t.Errorf("got %q want %q\n", err.Error(), want)
+	}
+
+	err = fs.Parse([]string{"-y"})
+	if e, ok := err.(*UnknownFlagError); !ok || len(e.Suggestions) != 0 {
+		t.Errorf("got %v want no suggestion\n", err)
+	}
+}
+
+func TestUnknownSubcommandSuggestions(t *testing.T) {
+	parent := NewParentCommand("tool")
+	parent.SetOutput(ioutil.Discard)
+	parent.SubCommand("ws, websocket", "websocket", func() {})
+	parent.SubCommand("http", "http", func() {})
+
+	err := parent.Parse([]string{"websoket"})
+	e, ok := err.(*UnknownSubcommandError)
+	if !ok {
+		t.Fatalf("got %T want *UnknownSubcommandError\n", err)
+	}
+
+	if e.Name != "websoket" || !reflect.DeepEqual(e.Suggestions, []string{"websocket"}) {
+		t.Errorf("got %+v\n", e)
+	}
+}
+
+func TestParseErrorTypes(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Opt("n", "number").NewInt(0)
+	fs.Opt("d, debug", "debug").NewBool(false)
+
+	err := fs.Parse([]string{"-n"})
+	if e, ok := err.(*MissingArgumentError); !ok || e.Flag != "n" || err.Error() != "flag needs an argument: -n" {
+		t.Errorf("got %#v\n", err)
+	}
+
+	err = fs.Parse([]string{"-n", "x"})
+	e, ok := err.(*InvalidValueError)
+	if !ok || e.Flag != "n" || e.Value != "x" || e.Unwrap() == nil {
+		t.Fatalf("got %#v\n", err)
+	}
+
+	if want := `invalid value "x" for flag -n: ` + e.Err.Error(); err.Error() != want {
+		t.Errorf("got %q want %q\n", err.Error(), want)
+	}
+
+	err = fs.Parse([]string{"--debug=x"})
+	if e, ok := err.(*InvalidValueError); !ok || e.Value != "x" ||
+		err.Error() != `invalid boolean value "x" for -debug: `+e.Err.Error() {
+		t.Errorf("got %#v\n", err)
+	}
+
+	err = fs.Parse([]string{"---n"})
+	if e, ok := err.(*BadSyntaxError); !ok || e.Arg != "---n" || err.Error() != "bad flag syntax: ---n" {
+		t.Errorf("got %#v\n", err)
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	var buf bytes.Buffer
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(&buf)
+	n := fs.Opt("n", "number").NewInt(0)
+	fs.Opt("d, debug", "debug").NewBool(false)
+	m := fs.Opt("m", "max").NewInt(0)
+
+	err := fs.ParseAll([]string{"-n", "x", "file", "--heder", "-m", "3", "---", "-n"})
+	errs, ok := err.(ParseErrors)
+	if !ok {
+		t.Fatalf("got %T want ParseErrors\n", err)
+	}
+
+	var got []string
+	for _, e := range errs {
+		got = append(got, fmt.Sprintf("%d %s %T", e.Index, e.Arg, e.Err))
+	}
+
+	want := []string{
+		"0 -n *flag.InvalidValueError",
+		"3 --heder *flag.UnknownFlagError",
+		"6 --- *flag.BadSyntaxError",
+		"7 -n *flag.MissingArgumentError",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q want %q\n", got, want)
+	}
+
+	if *n != 0 || *m != 3 {
+		t.Errorf("got n %d m %d want 0 3\n", *n, *m)
+	}
+
+	if !reflect.DeepEqual(fs.Args(), []string{"file"}) {
+		t.Errorf("args got %v want [file]\n", fs.Args())
+	}
+
+	if c := strings.Count(buf.String(), "Usage of test:"); c != 1 {
+		t.Errorf("usage printed %d times want 1\n", c)
+	}
+
+	if !strings.Contains(buf.String(), "argument 4 (--heder): flag provided but not defined: -heder") {
+		t.Errorf("got %q\n", buf.String())
+	}
+}
+
+func TestParseAllChecks(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Opt("n", "number").Required().NewInt(0)
+	fs.Opt("host", "server host").Required().NewString("")
+	fs.Opt("a", "a").NewInt(0)
+	fs.Opt("b", "b").NewInt(0)
+	fs.Opt("c", "c").NewInt(0)
+	fs.Opt("d", "d").NewInt(0)
+	fs.Opt("H", "header").MinLen(1).NewStringSlice([]string{})
+	fs.MutuallyExclusive("a", "b")
+	fs.MutuallyExclusive("c", "d")
+
+	err := fs.ParseAll([]string{"-n", "x", "-a", "1", "-b", "2", "-c", "3", "-d", "4"})
+	errs, ok := err.(ParseErrors)
+	if !ok {
+		t.Fatalf("got %T want ParseErrors\n", err)
+	}
+
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.Error())
+	}
+
+	want := []string{
+		`argument 1 (-n): invalid value "x" for flag -n: strconv.ParseInt: parsing "x": invalid syntax`,
+		"missing required flag: -host",
+		"flags cannot be used together: -a, -b",
+		"flags cannot be used together: -c, -d",
+		"flag -H needs at least 1 values, got 0",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q want %q\n", got, want)
+	}
+
+	fs.SetOutput(ioutil.Discard)
+	err = fs.Parse([]string{"-n", "1", "-host", "h", "-a", "1", "-b", "2", "-c", "3", "-d", "4"})
+	if _, ok := err.(*MutuallyExclusiveError); !ok {
+		t.Errorf("Parse should stop at the first problem, got %v\n", err)
+	}
+}
@@ -112,6 +112,9 @@ const (
 	GreedyMode
 	RegexKeyIsValue
 	NotValue
+	FileName      // the value is a file name, used by shell completion
+	Negatable     // a boolean flag also accepted as --no-NAME, which sets it to false
+	OptionalValue // the value may be omitted, NoOptDefVal is used then; --color[=WHEN]
 )
 
 // alias
@@ -154,6 +157,22 @@ type FlagSet struct {
 	errorHandling  ErrorHandling
 	output         io.Writer // nil means stderr; use out() accessor
 	openPosixShort bool
+	collecting     bool // set by ParseAll
+	abbreviations  bool
+	responseFiles  bool // see EnableResponseFiles
+
+	disableInterspersed bool // see SetInterspersed
+
+	positionals []*Flag // declared with NamedArg and ArgSlice
+
+	envPrefix      string
+	configDecoders map[string]ConfigDecoder
+	configPath     *string // value of the flag registered by ConfigFlag
+	groups         []flagGroup
+
+	// persistent holds the persistent flags of the parent commands,
+	// nearest first; they are accepted as if defined in the set.
+	persistent []*FlagSet
 }
 
 // A Flag represents the state of a flag.
@@ -163,12 +182,26 @@ type Flag struct {
 	Value    Value  // value as set
 	DefValue string // default value (as text); for usage message
 
+	// NoOptDefVal is the value of an OptionalValue flag given without one.
+	NoOptDefVal string
+
 	pointer interface{}
 	// 如果命令行匹配到Name，并且设置NotValue值，则使用MatchValue里面的值
 	matchValue interface{} // (flags & NotValue) == NotValue
 
 	parent *FlagSet
 	flags  Flags
+	env    string // environment variable used when the flag is not on the command line
+
+	required bool
+	enum     []string // allowed values, see Enum
+
+	validators []func(v interface{}) error
+	minLen     int
+	negated    bool // the no-NAME form of a Negatable flag
+	positional int  // set for positional arguments, see NamedArg
+
+	completer Completer
 
 	Regex    string
 	Short    []string
@@ -262,7 +295,7 @@ func (f *FlagSet) Set(name, value string) error {
 	if !ok {
 		return fmt.Errorf("no such flag -%v", name)
 	}
-	err := flag.Value.Set(value)
+	err := flag.set(value)
 	if err != nil {
 		return err
 	}
@@ -322,7 +355,11 @@ func UnquoteUsage(flag *Flag) (name string, usage string) {
 			break // Only one back quote; use type name.
 		}
 	}
-	// No explicit name, so use type if we can find one.
+	// No explicit name, so list the allowed values or use type if we can find one.
+	if len(flag.enum) > 0 {
+		return "{" + strings.Join(flag.enum, "|") + "}", usage
+	}
+
 	name = "value"
 	switch flag.Value.(type) {
 	case boolFlag:
@@ -350,33 +387,52 @@ func UnquoteUsage(flag *Flag) (name string, usage string) {
 // documentation for the global function PrintDefaults for more information.
 func (f *FlagSet) PrintDefaults() {
 	f.VisitAll(func(flag *Flag) {
-		name := strings.Replace(flag.Name, ", ", ", --", -1)
-		s := fmt.Sprintf("  -%s", name) // Two spaces before -; see next two comments.
-		name, usage := UnquoteUsage(flag)
-		if len(name) > 0 {
-			s += " " + name
-		}
-		// Boolean flags of one ASCII letter are so common we
-		// treat them specially, putting their usage on the same line.
-		if len(s) <= 4 { // space, space, '-', 'x'.
-			s += "\t"
+		f.printFlag(f.Output(), flag)
+	})
+	f.printPositionals(f.Output())
+}
+
+// printFlag prints the help line of flag to w.
+func (f *FlagSet) printFlag(w io.Writer, flag *Flag) {
+	name := strings.Replace(flag.Name, ", ", ", --", -1)
+	if flag.flags&Negatable > 0 {
+		name = negatableName(flag)
+	}
+	s := fmt.Sprintf("  -%s", name) // Two spaces before -; see next two comments.
+	name, usage := UnquoteUsage(flag)
+	if len(name) > 0 && flag.flags&OptionalValue > 0 {
+		s += "[=" + name + "]"
+	} else if len(name) > 0 {
+		s += " " + name
+	}
+	// Boolean flags of one ASCII letter are so common we
+	// treat them specially, putting their usage on the same line.
+	if len(s) <= 4 { // space, space, '-', 'x'.
+		s += "\t"
+	} else {
+		// Four spaces before the tab triggers good alignment
+		// for both 4- and 8-space tab stops.
+		s += "\n    \t"
+	}
+	s += strings.Replace(usage, "\n", "\n    \t", -1)
+
+	if !isZeroValue(flag, flag.DefValue) {
+		if _, ok := flag.Value.(*stringValue); ok {
+			// put quotes on the value
+			s += fmt.Sprintf(" (default %q)", flag.DefValue)
 		} else {
-			// Four spaces before the tab triggers good alignment
-			// for both 4- and 8-space tab stops.
-			s += "\n    \t"
+			s += fmt.Sprintf(" (default %v)", flag.DefValue)
 		}
-		s += strings.Replace(usage, "\n", "\n    \t", -1)
-
-		if !isZeroValue(flag, flag.DefValue) {
-			if _, ok := flag.Value.(*stringValue); ok {
-				// put quotes on the value
-				s += fmt.Sprintf(" (default %q)", flag.DefValue)
-			} else {
-				s += fmt.Sprintf(" (default %v)", flag.DefValue)
-			}
-		}
-		fmt.Fprint(f.Output(), s, "\n")
-	})
+	}
+
+	if env := f.envName(flag); env != "" {
+		s += fmt.Sprintf(" (env $%s)", env)
+	}
+
+	if flag.required {
+		s += " (required)"
+	}
+	fmt.Fprint(w, s, "\n")
 }
 
 // PrintDefaults prints, to standard error unless configured otherwise,
@@ -424,11 +480,12 @@ func (f *FlagSet) defaultUsage() {
 	f.printVersionAuthor()
 
 	if f.name == "" {
-		fmt.Fprintf(f.Output(), "Usage:\n")
+		fmt.Fprintf(f.Output(), "Usage:%s\n", f.usageLine())
 	} else {
-		fmt.Fprintf(f.Output(), "Usage of %s:\n", f.name)
+		fmt.Fprintf(f.Output(), "Usage of %s:%s\n", f.name, f.usageLine())
 	}
 	f.PrintDefaults()
+	printGlobalDefaults(f.Output(), f.persistent, f.formal)
 }
 
 // NOTE: Usage is not just defaultUsage(CommandLine)
@@ -446,7 +503,7 @@ func (f *FlagSet) defaultUsage() {
 // ExitOnError.
 var Usage = func() {
 	CommandLine.printVersionAuthor()
-	fmt.Fprintf(CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+	fmt.Fprintf(CommandLine.Output(), "Usage of %s:%s\n", os.Args[0], CommandLine.usageLine())
 	PrintDefaults()
 }
 
@@ -485,6 +542,21 @@ func (f *FlagSet) Args() []string { return f.args }
 // Args returns the non-flag command-line arguments.
 func Args() []string { return CommandLine.args }
 
+// SetInterspersed sets whether flags and non-flag arguments may be mixed.
+// It is true by default: "cmd file -v" sets -v and leaves file in Args.
+// With false, parsing stops at the first non-flag argument, "-" included,
+// like the standard library does; the rest, flags or not, is left in Args
+// for a wrapped command. In both modes "--" ends the flags.
+func (f *FlagSet) SetInterspersed(interspersed bool) *FlagSet {
+	f.disableInterspersed = !interspersed
+	return f
+}
+
+// SetInterspersed sets whether command-line flags and non-flag arguments may be mixed.
+func SetInterspersed(interspersed bool) {
+	CommandLine.SetInterspersed(interspersed)
+}
+
 // BoolVar defines a bool flag with specified name, default value, and usage string.
 // The argument p points to a bool variable in which to store the value of the flag.
 func (f *FlagSet) BoolVar(p *bool, name string, value bool, usage string) {
@@ -814,7 +886,16 @@ func Var(value Value, name string, usage string) {
 // failf prints to standard error a formatted error and usage message and
 // returns the error.
 func (f *FlagSet) failf(format string, a ...interface{}) error {
-	err := fmt.Errorf(format, a...)
+	return f.fail(fmt.Errorf(format, a...))
+}
+
+// fail prints to standard error err and a usage message and returns err.
+// Nothing is printed while ParseAll collects the errors.
+func (f *FlagSet) fail(err error) error {
+	if f.collecting {
+		return err
+	}
+
 	fmt.Fprintln(f.Output(), err)
 	f.usage()
 	return err
@@ -840,7 +921,12 @@ func parseNameValue(name string) (string, bool, string) {
 	return name, false, ""
 }
 
-func (f *FlagSet) getFlag(name string) (*Flag, bool, error) {
+func (f *FlagSet) getFlag(numMinuses int, name string) (*Flag, bool, error) {
+	name, err := f.expandFlag(numMinuses, name)
+	if err != nil {
+		return nil, false, err
+	}
+
 	formals := make([]map[string]*Flag, 0, 4)
 	formals = append(formals, f.formal, f.shortLong, f.regex)
 
@@ -874,7 +960,11 @@ func (f *FlagSet) getFlag(name string) (*Flag, bool, error) {
 		return flag, true, nil
 	}
 
-	return nil, false, fmt.Errorf("flag provided but not defined: -%s", name)
+	if flag := f.lookupPersistent(name); flag != nil {
+		return flag, true, nil
+	}
+
+	return nil, false, &UnknownFlagError{Name: name, Suggestions: f.flagSuggestions(name)}
 }
 
 func (f *FlagSet) setFlag(flag *Flag, name string, hasValue bool, value string) (bool, error) {
@@ -899,12 +989,12 @@ func (f *FlagSet) setValue(flag *Flag, name string, hasValue bool, value string)
 
 	if fv, ok := flag.Value.(boolFlag); ok && fv.IsBoolFlag() { // special case: doesn't need an arg
 		if hasValue {
-			if err := fv.Set(value); err != nil {
-				return false, f.failf("invalid boolean value %q for -%s: %v", value, name, err)
+			if err := flag.set(value); err != nil {
+				return false, f.fail(&InvalidValueError{Flag: name, Value: value, Err: err, boolean: true})
 			}
 		} else {
-			if err := fv.Set("true"); err != nil {
-				return false, f.failf("invalid boolean flag %s: %v", name, err)
+			if err := flag.set("true"); err != nil {
+				return false, f.fail(&InvalidValueError{Flag: name, Err: err, boolean: true})
 			}
 		}
 		return true, nil
@@ -912,10 +1002,15 @@ func (f *FlagSet) setValue(flag *Flag, name string, hasValue bool, value string)
 
 	if fv, ok := flag.Value.(*boolSlice); ok {
 		if err := fv.Set("true"); err != nil {
-			return false, f.failf("invalid boolean flag %s: %v", name, err)
+			return false, f.fail(&InvalidValueError{Flag: name, Err: err, boolean: true})
 		}
 		return true, nil
 	}
+	if !hasValue && flag.flags&OptionalValue > 0 {
+		hasValue = true
+		value = flag.NoOptDefVal
+	}
+
 	// It must have a value, which might be the next argument.
 	if !hasValue && len(f.args) > 0 {
 		// value is the next arg
@@ -923,11 +1018,11 @@ func (f *FlagSet) setValue(flag *Flag, name string, hasValue bool, value string)
 		value, f.args = f.args[0], f.args[1:]
 	}
 	if !hasValue {
-		return false, f.failf("flag needs an argument: -%s", name)
+		return false, f.fail(&MissingArgumentError{Flag: name})
 	}
 
-	if err := flag.Value.Set(value); err != nil {
-		return false, f.failf("invalid value %q for flag -%s: %v", value, name, err)
+	if err := flag.set(value); err != nil {
+		return false, f.fail(&InvalidValueError{Flag: name, Value: value, Err: err})
 	}
 
 	return true, nil
@@ -939,6 +1034,10 @@ func (f *FlagSet) getName(numMinuses *int, name *string, flags Flags) (bool, boo
 	}
 	s := f.args[0]
 	if len(s) < 2 || s[0] != '-' {
+		if f.disableInterspersed && flags&GreedyMode != GreedyMode {
+			return false, false, nil // the flags end at the first argument
+		}
+
 		if flags&GreedyMode != GreedyMode {
 			f.unkownArgs = append(f.unkownArgs, s)
 		}
@@ -958,7 +1057,7 @@ func (f *FlagSet) getName(numMinuses *int, name *string, flags Flags) (bool, boo
 
 	*name = s[*numMinuses:]
 	if len(*name) == 0 || (*name)[0] == '-' || (*name)[0] == '=' {
-		return false, false, f.failf("bad flag syntax: %s", s)
+		return false, false, f.fail(&BadSyntaxError{Arg: s})
 	}
 
 	return true, true, nil
@@ -969,7 +1068,7 @@ func (f *FlagSet) setPosixShortOpt(numMinuses int, name string) (bool, bool, err
 	for i := range name {
 		newName := string(name[i])
 
-		flag, seen, err := f.getFlag(newName)
+		flag, seen, err := f.getFlag(numMinuses, newName)
 		if err != nil {
 			continue
 		}
@@ -1032,7 +1131,7 @@ func (f *FlagSet) setPosix(seen bool, err error, numMinuses int, name string) (b
 			return next, seen, err
 		}
 	}
-	return false, false, f.failf("%s", err.Error())
+	return false, false, f.fail(err)
 }
 
 // parseOne parses one flag. It reports whether a flag was seen.
@@ -1054,7 +1153,7 @@ func (f *FlagSet) parseOne() (bool, error) {
 		err0 error
 	)
 
-	if flag, seen, err0 = f.getFlag(name); err0 != nil {
+	if flag, seen, err0 = f.getFlag(numMinuses, name); err0 != nil {
 		if next, seen, err0 = f.setPosix(seen, err0, numMinuses, name); !next {
 			return seen, err0
 		}
@@ -1077,7 +1176,7 @@ try:
 			if next {
 				name, hasValue, value = parseNameValue(name)
 				//fmt.Printf("---> name(%s), hasValue(%t), value(%s) args(%s)\n", name, hasValue, value, f.args)
-				if flag, seen, err0 = f.getFlag(name); err0 != nil {
+				if flag, seen, err0 = f.getFlag(numMinuses, name); err0 != nil {
 					if next, seen, err0 = f.setPosix(seen, err0, numMinuses, name); !next {
 						return seen, err0
 					}
@@ -1110,15 +1209,44 @@ func (f *FlagSet) generatedOpt() {
 // are defined and before flags are accessed by the program.
 // The return value will be ErrHelp if -help or -h were set but not defined.
 func (f *FlagSet) Parse(arguments []string) error {
+	return f.parse(arguments, false)
+}
+
+// ParseAll is like Parse but does not stop at the first bad flag: it goes
+// on to the end of the argument list and returns a ParseErrors listing every
+// problem found. The usage message is printed once, after the errors.
+func (f *FlagSet) ParseAll(arguments []string) error {
+	return f.parse(arguments, true)
+}
+
+func (f *FlagSet) parse(arguments []string, collect bool) error {
 
 	f.parsed = true
+
+	if len(arguments) > 0 && arguments[0] == completeCommand {
+		printCompletions(completeOutput, f.complete(arguments[1:]))
+		return f.handleError(ErrComplete)
+	}
+
+	if f.responseFiles {
+		var err error
+		if arguments, err = expandResponseFiles(arguments); err != nil {
+			return f.handleError(f.fail(err))
+		}
+	}
+
 	f.args = arguments
+	f.unkownArgs = nil // left over by a previous Parse
+	f.collecting = collect
 
 	defer func() {
 		f.args = append(f.unkownArgs, f.args...)
+		f.collecting = false
 	}()
 
+	var errs ParseErrors
 	for {
+		n := len(f.args)
 		seen, err := f.parseOne()
 		if seen {
 			continue
@@ -1126,16 +1254,77 @@ func (f *FlagSet) Parse(arguments []string) error {
 		if err == nil {
 			break
 		}
-		switch f.errorHandling {
-		case ContinueOnError:
-			return err
-		case ExitOnError:
-			os.Exit(2)
-		case PanicOnError:
-			panic(err)
+		if !collect || err == ErrHelp || err == ErrVersion {
+			return f.handleError(err)
+		}
+
+		i := len(arguments) - n
+		errs = append(errs, &ArgError{Index: i, Arg: arguments[i], Err: err})
+		if len(f.args) == n {
+			f.args = f.args[1:] // skip the argument in error
 		}
 	}
-	return nil
+
+	// the other sources and the checks; ParseAll runs them all
+	var post []error
+	for _, source := range []func() error{
+		func() error { return f.parsePositionals(append(append([]string{}, f.unkownArgs...), f.args...)) },
+		f.parseEnv,
+		func() error {
+			if f.configPath == nil || *f.configPath == "" {
+				return nil
+			}
+			return f.parseFile(*f.configPath)
+		},
+	} {
+		if err := source(); err != nil {
+			post = append(post, err)
+			if !collect {
+				break
+			}
+		}
+	}
+
+	if len(post) == 0 || collect {
+		post = append(post, f.checkFlags(errs.failedFlags(f))...)
+	}
+
+	if !collect {
+		if len(post) == 0 {
+			return nil
+		}
+		return f.handleError(post[0])
+	}
+
+	for _, err := range post {
+		errs = append(errs, &ArgError{Index: -1, Err: err})
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(f.Output(), errs)
+	f.usage()
+	return f.handleError(errs)
+}
+
+// handleError applies the error handling policy of the flag set to err.
+func (f *FlagSet) handleError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch f.errorHandling {
+	case ExitOnError:
+		if err == ErrComplete {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	case PanicOnError:
+		panic(err)
+	}
+	return err
 }
 
 // Parsed reports whether f.Parse has been called.
@@ -1150,6 +1339,13 @@ func Parse() {
 	CommandLine.Parse(os.Args[1:])
 }
 
+// ParseAll parses the command-line flags from os.Args[1:] like Parse, but
+// reports all the bad flags at once.
+func ParseAll() {
+	// Ignore errors; CommandLine is set for ExitOnError.
+	CommandLine.ParseAll(os.Args[1:])
+}
+
 // Parsed reports whether the command-line flags have been parsed.
 func Parsed() bool {
 	return CommandLine.Parsed()
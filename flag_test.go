@@ -500,3 +500,33 @@ func TestGetters(t *testing.T) {
 		}
 	*/
 }
+
+func TestSetInterspersed(t *testing.T) {
+	tests := []struct {
+		interspersed bool
+		args         []string
+		verbose      bool
+		rest         []string
+	}{
+		{true, []string{"file", "-v"}, true, []string{"file"}},
+		{true, []string{"-", "-v", "--", "-x"}, true, []string{"-", "-x"}},
+		{false, []string{"-v", "ssh", "-v", "host"}, true, []string{"ssh", "-v", "host"}},
+		{false, []string{"file", "-v"}, false, []string{"file", "-v"}},
+		{false, []string{"-", "-v"}, false, []string{"-", "-v"}},
+		{false, []string{"--", "-v"}, false, []string{"-v"}},
+	}
+
+	for _, test := range tests {
+		fs := NewFlagSet("test", ContinueOnError)
+		fs.SetInterspersed(test.interspersed)
+		verbose := fs.Bool("v", false, "verbose")
+
+		if err := fs.Parse(test.args); err != nil {
+			t.Fatalf("Parse(%q) got %v\n", test.args, err)
+		}
+
+		if *verbose != test.verbose || fmt.Sprint(fs.Args()) != fmt.Sprint(test.rest) {
+			t.Errorf("interspersed %t Parse(%q) got verbose %t args %q\n", test.interspersed, test.args, *verbose, fs.Args())
+		}
+	}
+}
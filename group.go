@@ -0,0 +1,96 @@
+package flag
+
+import "fmt"
+
+type groupKind int
+
+const (
+	mutuallyExclusive groupKind = iota
+	oneRequired
+	requiredTogether
+)
+
+// flagGroup is a constraint on a set of flags, checked at the end of Parse.
+type flagGroup struct {
+	kind  groupKind
+	names []string
+}
+
+func (f *FlagSet) addGroup(kind groupKind, names []string) {
+	if len(names) < 2 {
+		panic(fmt.Sprintf("flag group needs at least two flags: %v", names))
+	}
+	f.groups = append(f.groups, flagGroup{kind: kind, names: names})
+}
+
+// MutuallyExclusive makes Parse fail with a MutuallyExclusiveError when
+// more than one of the named flags is set. Any name of a flag can be used.
+func (f *FlagSet) MutuallyExclusive(names ...string) {
+	f.addGroup(mutuallyExclusive, names)
+}
+
+// OneRequired makes Parse fail with a OneRequiredError when none of the
+// named flags is set.
+func (f *FlagSet) OneRequired(names ...string) {
+	f.addGroup(oneRequired, names)
+}
+
+// RequiredTogether makes Parse fail with a RequiredTogetherError when some,
+// but not all, of the named flags are set.
+func (f *FlagSet) RequiredTogether(names ...string) {
+	f.addGroup(requiredTogether, names)
+}
+
+// MutuallyExclusive makes the command-line flags names mutually exclusive.
+func MutuallyExclusive(names ...string) {
+	CommandLine.MutuallyExclusive(names...)
+}
+
+// OneRequired requires one of the command-line flags names.
+func OneRequired(names ...string) {
+	CommandLine.OneRequired(names...)
+}
+
+// RequiredTogether requires the command-line flags names to be used together.
+func RequiredTogether(names ...string) {
+	CommandLine.RequiredTogether(names...)
+}
+
+// checkGroups checks the flag groups against the flags that were set.
+// It stops at the first problem unless ParseAll collects the errors.
+func (f *FlagSet) checkGroups() []error {
+	var errs []error
+	for _, g := range f.groups {
+		var set, unset []string
+		for _, name := range g.names {
+			flag := f.lookupInherited(name)
+			if flag == nil {
+				panic(fmt.Sprintf("flag group: no such flag -%s", name))
+			}
+
+			if f.isSet(flag) {
+				set = append(set, name)
+			} else {
+				unset = append(unset, name)
+			}
+		}
+
+		var err error
+		switch {
+		case g.kind == mutuallyExclusive && len(set) > 1:
+			err = &MutuallyExclusiveError{Flags: set}
+		case g.kind == oneRequired && len(set) == 0:
+			err = &OneRequiredError{Flags: g.names}
+		case g.kind == requiredTogether && len(set) > 0 && len(unset) > 0:
+			err = &RequiredTogetherError{Flags: g.names, Missing: unset}
+		default:
+			continue
+		}
+
+		errs = append(errs, f.fail(err))
+		if !f.collecting {
+			break
+		}
+	}
+	return errs
+}
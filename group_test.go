@@ -0,0 +1,48 @@
+package flag
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func newGroupFlagSet() *FlagSet {
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Opt("j, json", "json output").NewBool(false)
+	fs.Opt("y, yaml", "yaml output").NewBool(false)
+	fs.Opt("id", "resource id").NewString("")
+	fs.Opt("name", "resource name").NewString("")
+	fs.Opt("u, user", "user").NewString("")
+	fs.Opt("p, password", "password").NewString("")
+
+	fs.MutuallyExclusive("json", "yaml")
+	fs.OneRequired("id", "name")
+	fs.RequiredTogether("user", "password")
+	return fs
+}
+
+func TestFlagGroups(t *testing.T) {
+	if err := newGroupFlagSet().Parse([]string{"-j", "-id", "1"}); err != nil {
+		t.Errorf("got %v want nil\n", err)
+	}
+
+	err := newGroupFlagSet().Parse([]string{"-j", "--yaml", "-id", "1"})
+	if e, ok := err.(*MutuallyExclusiveError); !ok || !reflect.DeepEqual(e.Flags, []string{"json", "yaml"}) {
+		t.Errorf("got %v want *MutuallyExclusiveError\n", err)
+	}
+
+	err = newGroupFlagSet().Parse([]string{"-j"})
+	if _, ok := err.(*OneRequiredError); !ok || err.Error() != "one of the flags is required: -id, -name" {
+		t.Errorf("got %v want *OneRequiredError\n", err)
+	}
+
+	err = newGroupFlagSet().Parse([]string{"-name", "n", "-u", "root"})
+	if e, ok := err.(*RequiredTogetherError); !ok || !reflect.DeepEqual(e.Missing, []string{"password"}) {
+		t.Errorf("got %v want *RequiredTogetherError\n", err)
+	}
+
+	if err := newGroupFlagSet().Parse([]string{"-name", "n", "-u", "root", "-p", "x"}); err != nil {
+		t.Errorf("got %v want nil\n", err)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -32,6 +34,11 @@ func (f *FlagSet) setNamesToMap(m *map[string]*Flag, names []string, flag *Flag)
 
 func (f *FlagSet) flagVar(flag *Flag) {
 
+	if flag.positional != 0 {
+		f.addPositional(flag)
+		return
+	}
+
 	if flag.flags&PosixShort > 0 && flag.flags&GreedyMode > 0 {
 		panic("Cannot set both PosixShort and GreedyMode")
 	}
@@ -62,6 +69,64 @@ func (f *FlagSet) flagVar(flag *Flag) {
 	initFormal(&f.formal)
 
 	f.formal[name] = flag
+
+	if flag.flags&Negatable > 0 {
+		f.setNegated(flag)
+	}
+}
+
+// setNegated registers no-NAME for each long name of flag, a boolean flag.
+func (f *FlagSet) setNegated(flag *Flag) {
+	if fv, ok := flag.Value.(boolFlag); !ok || !fv.IsBoolFlag() {
+		panic(fmt.Sprintf("%s: Negatable needs a boolean flag", flag.Name))
+	}
+
+	initFormal(&f.shortLong)
+	for _, name := range flagNames(flag) {
+		if len(name) < 2 {
+			continue
+		}
+
+		no := "no-" + name
+		if _, alreadythere := f.shortLong[no]; alreadythere {
+			f.alreadythereError(no)
+		}
+
+		newFlag := *flag
+		newFlag.Name = no
+		newFlag.negated = true
+		f.shortLong[no] = &newFlag
+	}
+}
+
+// negatableName returns the names of flag, a Negatable flag, for the help
+// message: -c, --[no-]color.
+func negatableName(flag *Flag) string {
+	names := flagNames(flag)
+	for k, name := range names {
+		switch {
+		case len(name) > 1:
+			names[k] = "-[no-]" + name
+		case k > 0:
+			names[k] = "-" + name
+		}
+	}
+	return strings.Join(names, ", -")
+}
+
+// flagNames returns the short and long names of flag, without the regex.
+func flagNames(flag *Flag) []string {
+	if flag.isOptOpt {
+		names := make([]string, 0, len(flag.Short)+len(flag.Long))
+		names = append(names, flag.Short...)
+		return append(names, flag.Long...)
+	}
+
+	names := strings.Split(flag.Name, ",")
+	for k := range names {
+		names[k] = strings.TrimSpace(names[k])
+	}
+	return names
 }
 
 func (f *FlagSet) OptOpt(opt Flag) *Flag {
@@ -122,6 +187,90 @@ func (f *Flag) Flags(flag Flags) *Flag {
 	return f
 }
 
+// Env binds the flag to the environment variable name. The variable is
+// consulted by Parse when the flag is not given on the command line.
+func (f *Flag) Env(name string) *Flag {
+	f.env = name
+	return f
+}
+
+// NoOptDefault makes the value of the flag optional: given without one,
+// as in --color rather than --color=never, the flag is set to value and
+// the next argument is left alone.
+func (f *Flag) NoOptDefault(value string) *Flag {
+	f.NoOptDefVal = value
+	return f.Flags(OptionalValue)
+}
+
+// Required makes Parse fail with a MissingRequiredError when the flag is
+// set neither on the command line nor by the environment or a configuration file.
+func (f *Flag) Required() *Flag {
+	f.required = true
+	return f
+}
+
+// Enum restricts the values of the flag to values. For slice flags every
+// element is checked.
+func (f *Flag) Enum(values ...string) *Flag {
+	f.enum = values
+	return f
+}
+
+// set sets the value of the flag to value, if allowed, and validates the result.
+// A flag bound with MatchVar takes a boolean instead: true stores its match
+// value, as the flag given on the command line does, false leaves it alone.
+func (f *Flag) set(value string) error {
+	if f.flags&NotValue > 0 {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		if b {
+			reflect.ValueOf(f.pointer).Elem().Set(reflect.ValueOf(f.matchValue))
+		}
+		return nil
+	}
+
+	if len(f.enum) > 0 {
+		allowed := false
+		for _, v := range f.enum {
+			if v == value {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return fmt.Errorf("must be one of %s", strings.Join(f.enum, "|"))
+		}
+	}
+
+	if f.negated {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		value = strconv.FormatBool(!b)
+	}
+
+	if len(f.validators) == 0 {
+		return f.Value.Set(value)
+	}
+
+	// a value rejected by the validators must not stay in the variable
+	restore := snapshot(f.Value)
+	if err := f.Value.Set(value); err != nil {
+		return err
+	}
+
+	if err := f.validate(); err != nil {
+		restore()
+		return err
+	}
+	return nil
+}
+
 type InvalidVarError struct {
 	Type reflect.Type
 }
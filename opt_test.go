@@ -1,6 +1,9 @@
 package flag
 
 import (
+	"bytes"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -347,3 +350,102 @@ func TestOptBoolSlice(t *testing.T) {
 	testOptBoolSlice2(t)
 	testOptBoolSlice3(t)
 }
+
+func TestOptEnum(t *testing.T) {
+	var buf bytes.Buffer
+	fs := NewFlagSet("test-enum", ContinueOnError)
+	fs.SetOutput(&buf)
+	format := fs.Opt("f, format", "output format").Enum("json", "yaml", "table").NewString("json")
+	columns := fs.Opt("c", "columns").Flags(PosixShort).Enum("id", "name").NewStringSlice([]string{})
+
+	if err := fs.Parse([]string{"-f", "yaml", "-c", "id", "-cname"}); err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	if *format != "yaml" || !reflect.DeepEqual(*columns, []string{"id", "name"}) {
+		t.Errorf("got format %s columns %v\n", *format, *columns)
+	}
+
+	err := fs.Parse([]string{"--format=xml"})
+	want := `invalid value "xml" for flag -format: must be one of json|yaml|table`
+	if err == nil || err.Error() != want {
+		t.Errorf("got %v want %s\n", err, want)
+	}
+
+	if err := fs.Parse([]string{"-c", "age"}); err == nil {
+		t.Errorf("slice element should be checked\n")
+	}
+
+	if !strings.Contains(buf.String(), "-f, --format {json|yaml|table}") {
+		t.Errorf("help should list the values, got %q\n", buf.String())
+	}
+}
+
+func TestOptNegatable(t *testing.T) {
+	var buf bytes.Buffer
+	fs := NewFlagSet("test-negatable", ContinueOnError)
+	fs.SetOutput(&buf)
+	color := fs.Opt("c, color", "colorize the output").Flags(PosixShort | Negatable).NewBool(true)
+	verbose := fs.Opt("v", "verbose").Flags(PosixShort).NewBool(false)
+
+	if err := fs.Parse([]string{"--no-color"}); err != nil || *color {
+		t.Errorf("got %v color %t want color false\n", err, *color)
+	}
+
+	if err := fs.Parse([]string{"-vc"}); err != nil || !*color || !*verbose {
+		t.Errorf("got %v color %t verbose %t\n", err, *color, *verbose)
+	}
+
+	if err := fs.Parse([]string{"--no-color=false"}); err != nil || !*color {
+		t.Errorf("got %v color %t want color true\n", err, *color)
+	}
+
+	fs.PrintDefaults()
+	if !strings.Contains(buf.String(), "-c, --[no-]color") {
+		t.Errorf("help should show --[no-]color, got %q\n", buf.String())
+	}
+}
+
+func TestOptOptionalValue(t *testing.T) {
+	var buf bytes.Buffer
+	newFlagSet := func() (*FlagSet, *string, *bool) {
+		fs := NewFlagSet("test-optional", ContinueOnError)
+		fs.SetOutput(&buf)
+		color := fs.Opt("c, color", "colorize the output; `WHEN` is always, never or auto").
+			Flags(PosixShort).NoOptDefault("auto").NewString("never")
+		verbose := fs.Opt("v", "verbose").Flags(PosixShort).NewBool(false)
+		return fs, color, verbose
+	}
+
+	tests := []struct {
+		args  []string
+		color string
+		rest  []string
+	}{
+		{[]string{"--color", "file"}, "auto", []string{"file"}},
+		{[]string{"--color=always", "file"}, "always", []string{"file"}},
+		{[]string{"-cnever"}, "never", []string{}},
+		{[]string{"-vc", "file"}, "auto", []string{"file"}},
+	}
+
+	for _, test := range tests {
+		fs, color, _ := newFlagSet()
+		if err := fs.Parse(test.args); err != nil {
+			t.Fatalf("Parse(%q) got %v\n", test.args, err)
+		}
+
+		if *color != test.color || len(fs.Args()) != len(test.rest) {
+			t.Errorf("Parse(%q) got color %s args %v\n", test.args, *color, fs.Args())
+		}
+	}
+
+	fs, _, verbose := newFlagSet()
+	if fs.Parse([]string{"-vc"}); !*verbose {
+		t.Errorf("verbose got false want true\n")
+	}
+
+	fs.PrintDefaults()
+	if !strings.Contains(buf.String(), "-c, --color[=WHEN]") {
+		t.Errorf("help should show --color[=WHEN], got %q\n", buf.String())
+	}
+}
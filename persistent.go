@@ -0,0 +1,204 @@
+package flag
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PersistentFlags returns the flag set holding the persistent flags of the
+// command. Persistent flags are accepted before the subcommand name as well
+// as after it, by every subcommand below p, and are listed under "Global
+// options" in the help of those subcommands.
+//
+// Subcommands registered with SubCommand parse their own flags, so the
+// persistent flags are removed from Args before their function is called.
+func (p *ParentCommand) PersistentFlags() *FlagSet {
+	if p.flags == nil {
+		p.flags = NewFlagSet(p.name, ContinueOnError)
+		p.flags.Usage = p.usage
+	}
+	return p.flags
+}
+
+// persistentFlags returns the persistent flag sets of p and of its parent
+// commands, nearest first.
+func (p *ParentCommand) persistentFlags() []*FlagSet {
+	var sets []*FlagSet
+	for c := p; c != nil; c = c.parent {
+		if c.flags != nil {
+			sets = append(sets, c.flags)
+		}
+	}
+	return sets
+}
+
+// inherit makes fs, the flag set of a subcommand of p, accept the
+// persistent flags of p and of its parent commands.
+func (p *ParentCommand) inherit(fs *FlagSet) {
+	fs.persistent = p.persistentFlags()
+}
+
+// bindPersistent prepares the persistent flag set of p for parsing.
+// It returns nil when neither p nor its parent commands have one.
+func (p *ParentCommand) bindPersistent() *FlagSet {
+	if len(p.persistentFlags()) == 0 {
+		return nil
+	}
+
+	fs := p.PersistentFlags()
+	if fs.output == nil {
+		fs.SetOutput(p.Output())
+	}
+
+	fs.persistent = nil
+	if p.parent != nil {
+		fs.persistent = p.parent.persistentFlags()
+	}
+	return fs
+}
+
+// parsePersistent parses the persistent flags found before the subcommand name.
+func (p *ParentCommand) parsePersistent() error {
+	fs := p.bindPersistent()
+	if fs == nil {
+		return nil
+	}
+
+	fs.args = p.args
+	for len(fs.args) > 0 {
+		s := fs.args[0]
+		if s == "--" {
+			fs.args = fs.args[1:]
+			break
+		}
+
+		if len(s) < 2 || s[0] != '-' {
+			break
+		}
+
+		if _, err := fs.parseOne(); err != nil {
+			return err
+		}
+	}
+
+	p.args, fs.args = fs.args, nil
+	return nil
+}
+
+// extractPersistent sets the persistent flags found in arguments, the
+// arguments of a subcommand registered with SubCommand, and returns the
+// other arguments. Afterwards the persistent flags not given on the command
+// line are read from the environment, and the Required flags and the flag
+// groups are checked.
+func (p *ParentCommand) extractPersistent(arguments []string) ([]string, error) {
+	fs := p.bindPersistent()
+	if fs == nil {
+		return arguments, nil
+	}
+
+	var rest []string
+	fs.args = arguments
+	for len(fs.args) > 0 {
+		s := fs.args[0]
+		if s == "--" {
+			rest = append(rest, fs.args...)
+			break
+		}
+		fs.args = fs.args[1:]
+
+		var flag *Flag
+		name, hasValue, value := "", false, ""
+		if len(s) > 1 && s[0] == '-' {
+			name, hasValue, value = parseNameValue(strings.TrimLeft(s, "-"))
+			flag = fs.lookupInherited(name)
+		}
+
+		// the help and version flags belong to the subcommand
+		if flag == nil || isGeneratedFlag(flag) {
+			rest = append(rest, s)
+			continue
+		}
+
+		if _, err := fs.setFlag(flag, name, hasValue, value); err != nil {
+			fs.args = nil
+			return nil, err
+		}
+	}
+
+	fs.args = nil
+	if err := fs.parseEnv(); err != nil {
+		return nil, err
+	}
+
+	if errs := fs.checkFlags(nil); len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return rest, nil
+}
+
+// isGeneratedFlag reports whether flag is the help or version flag every
+// FlagSet defines.
+func isGeneratedFlag(flag *Flag) bool {
+	return flag.Name == "h, help" || flag.Name == "V, version"
+}
+
+// lookupInherited finds the flag named name in f or in the persistent
+// flags it inherits, without side effects.
+func (f *FlagSet) lookupInherited(name string) *Flag {
+	if flag := f.lookupFlag(name); flag != nil {
+		return flag
+	}
+	return f.lookupPersistent(name)
+}
+
+// lookupPersistent finds the flag named name in the persistent flags f inherits.
+func (f *FlagSet) lookupPersistent(name string) *Flag {
+	for _, set := range f.persistent {
+		if flag := set.lookupFlag(name); flag != nil && !isGeneratedFlag(flag) {
+			return flag
+		}
+	}
+	return nil
+}
+
+// visitGlobal calls fn, in lexicographical order, for each flag of sets
+// along with the set defining it. Help and version flags, flags hidden by
+// a nearer set and flags named in own are skipped.
+func visitGlobal(sets []*FlagSet, own map[string]*Flag, fn func(set *FlagSet, flag *Flag)) {
+	seen := make(map[string]bool)
+	for name := range own {
+		seen[name] = true
+	}
+
+	for _, set := range sets {
+		for _, flag := range sortFlags(set.formal) {
+			if isGeneratedFlag(flag) || seen[flag.Name] {
+				continue
+			}
+			seen[flag.Name] = true
+			fn(set, flag)
+		}
+	}
+}
+
+// globalFlags returns the persistent flags f inherits.
+func (f *FlagSet) globalFlags() []*Flag {
+	var flags []*Flag
+	visitGlobal(f.persistent, f.formal, func(set *FlagSet, flag *Flag) {
+		flags = append(flags, flag)
+	})
+	return flags
+}
+
+// printGlobalDefaults prints the flags of sets to w under a "Global options" header.
+func printGlobalDefaults(w io.Writer, sets []*FlagSet, own map[string]*Flag) {
+	header := false
+	visitGlobal(sets, own, func(set *FlagSet, flag *Flag) {
+		if !header {
+			fmt.Fprint(w, "\nGlobal options:\n")
+			header = true
+		}
+		set.printFlag(w, flag)
+	})
+}
@@ -0,0 +1,162 @@
+package flag
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// NamedArg declares the next positional argument, bound like a flag with
+// one of the New or Var methods of the returned Flag:
+//
+//	src := fs.NamedArg("src", "source file").Required().NewString("")
+//
+// Parse assigns the arguments left after the flags to the declared
+// positional arguments, in order, and fails when a Required one is
+// missing or when there are too many arguments. Args still returns
+// all of them.
+func (f *FlagSet) NamedArg(name string, usage string) *Flag {
+	return &Flag{Name: name, Usage: usage, parent: f, positional: positionalOne}
+}
+
+// ArgSlice declares a positional argument taking any number of arguments;
+// it must be bound to a slice. The arguments are split between the
+// positional arguments declared before and after it: cp SRC... DST.
+// Required asks for at least one argument.
+func (f *FlagSet) ArgSlice(name string, usage string) *Flag {
+	return &Flag{Name: name, Usage: usage, parent: f, positional: positionalSlice}
+}
+
+// NamedArg declares a positional argument of the command line.
+func NamedArg(name string, usage string) *Flag {
+	return CommandLine.NamedArg(name, usage)
+}
+
+// ArgSlice declares a positional argument of the command line taking any
+// number of arguments.
+func ArgSlice(name string, usage string) *Flag {
+	return CommandLine.ArgSlice(name, usage)
+}
+
+const (
+	positionalOne = iota + 1
+	positionalSlice
+)
+
+// addPositional registers flag, declared with NamedArg or ArgSlice.
+func (f *FlagSet) addPositional(flag *Flag) {
+	for _, p := range f.positionals {
+		if p.Name == flag.Name {
+			f.alreadythereError(flag.Name)
+		}
+
+		if p.positional == positionalSlice && flag.positional == positionalSlice {
+			panic(fmt.Sprintf("%s: only one ArgSlice is allowed", flag.Name))
+		}
+	}
+
+	if flag.positional == positionalSlice && !isSliceValue(flag.Value) {
+		panic(fmt.Sprintf("%s: ArgSlice needs a slice", flag.Name))
+	}
+
+	f.positionals = append(f.positionals, flag)
+}
+
+// positionalName returns the name of a positional argument in the usage line.
+func positionalName(flag *Flag) string {
+	name := strings.ToUpper(flag.Name)
+	if flag.positional == positionalSlice {
+		name += "..."
+	}
+
+	if !flag.required {
+		name = "[" + name + "]"
+	}
+	return name
+}
+
+// usageLine returns what follows the command name in the usage message
+// when positional arguments are declared: " [options] SRC... DST".
+func (f *FlagSet) usageLine() string {
+	if len(f.positionals) == 0 {
+		return ""
+	}
+
+	words := []string{"", "[options]"}
+	for _, flag := range f.positionals {
+		words = append(words, positionalName(flag))
+	}
+	return strings.Join(words, " ")
+}
+
+// printPositionals prints the usage of the positional arguments to w.
+func (f *FlagSet) printPositionals(w io.Writer) {
+	if len(f.positionals) == 0 {
+		return
+	}
+
+	fmt.Fprint(w, "\nArguments:\n")
+	for _, flag := range f.positionals {
+		_, usage := UnquoteUsage(flag)
+		fmt.Fprintf(w, "  %s\n    \t%s\n", positionalName(flag), strings.Replace(usage, "\n", "\n    \t", -1))
+	}
+}
+
+// parsePositionals assigns args, the arguments left after the flags, to the
+// declared positional arguments.
+func (f *FlagSet) parsePositionals(args []string) error {
+	if len(f.positionals) == 0 {
+		return nil
+	}
+
+	// the number of arguments each positional argument takes
+	counts := make([]int, len(f.positionals))
+	var required []string
+	slice := -1
+	for i, flag := range f.positionals {
+		if flag.positional == positionalSlice {
+			slice = i
+			continue
+		}
+
+		if flag.required {
+			counts[i] = 1
+			required = append(required, strings.ToUpper(flag.Name))
+		}
+	}
+
+	if len(args) < len(required) {
+		return f.failf("missing argument %s", required[len(args)])
+	}
+	left := len(args) - len(required)
+
+	// optional arguments are filled from left to right
+	for i, flag := range f.positionals {
+		if left > 0 && i != slice && !flag.required {
+			counts[i] = 1
+			left--
+		}
+	}
+
+	if slice != -1 {
+		counts[slice] = left
+		left = 0
+		if f.positionals[slice].required && counts[slice] == 0 {
+			return f.failf("missing argument %s", strings.ToUpper(f.positionals[slice].Name))
+		}
+	}
+
+	if left > 0 {
+		return f.failf("too many arguments: %s", strings.Join(args[len(args)-left:], " "))
+	}
+
+	for i, flag := range f.positionals {
+		for _, value := range args[:counts[i]] {
+			if err := flag.set(value); err != nil {
+				return f.failf("invalid value %q for argument %s: %v", value, strings.ToUpper(flag.Name), err)
+			}
+		}
+		args = args[counts[i]:]
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package flag
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newCopyFlagSet(buf *bytes.Buffer) (*FlagSet, *[]string, *string) {
+	fs := NewFlagSet("cp", ContinueOnError)
+	fs.SetOutput(buf)
+	fs.Opt("r, recursive", "copy directories recursively").NewBool(false)
+	src := fs.ArgSlice("src", "source files").Required().NewStringSlice([]string{})
+	dst := fs.NamedArg("dst", "destination").Required().NewString("")
+	return fs, src, dst
+}
+
+func TestPositional(t *testing.T) {
+	var buf bytes.Buffer
+	fs, src, dst := newCopyFlagSet(&buf)
+
+	if err := fs.Parse([]string{"a", "-r", "b", "dir"}); err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	if !reflect.DeepEqual(*src, []string{"a", "b"}) || *dst != "dir" {
+		t.Errorf("got src %v dst %q\n", *src, *dst)
+	}
+
+	if !reflect.DeepEqual(fs.Args(), []string{"a", "b", "dir"}) {
+		t.Errorf("Args should keep the positional arguments, got %v\n", fs.Args())
+	}
+}
+
+func TestPositionalParseTwice(t *testing.T) {
+	fs := NewFlagSet("rm", ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	file := fs.NamedArg("file", "file to remove").Required().NewString("")
+
+	for _, name := range []string{"a", "b"} {
+		if err := fs.Parse([]string{name}); err != nil {
+			t.Fatalf("Parse(%s) got %v want nil\n", name, err)
+		}
+
+		if *file != name || !reflect.DeepEqual(fs.Args(), []string{name}) {
+			t.Errorf("got %s args %v want %s\n", *file, fs.Args(), name)
+		}
+	}
+}
+
+func TestPositionalCount(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"a"}, "missing argument SRC"},
+		{[]string{}, "missing argument DST"},
+	} {
+		var buf bytes.Buffer
+		fs, _, _ := newCopyFlagSet(&buf)
+		if err := fs.Parse(test.args); err == nil || err.Error() != test.err {
+			t.Errorf("%v: got %v want %s\n", test.args, err, test.err)
+		}
+	}
+
+	newMove := func() (*FlagSet, *int) {
+		fs := NewFlagSet("mv", ContinueOnError)
+		fs.SetOutput(&bytes.Buffer{})
+		fs.NamedArg("src", "source").Required().NewString("")
+		return fs, fs.NamedArg("n", "count").NewInt(1)
+	}
+
+	fs, n := newMove()
+	if err := fs.Parse([]string{"a"}); err != nil || *n != 1 {
+		t.Errorf("got %v, %d want nil, 1\n", err, *n)
+	}
+
+	fs, _ = newMove()
+	err := fs.Parse([]string{"a", "b"})
+	if err == nil || !strings.HasPrefix(err.Error(), `invalid value "b" for argument N`) {
+		t.Errorf("got %v want invalid value\n", err)
+	}
+
+	fs, _ = newMove()
+	if err := fs.Parse([]string{"a", "3", "c"}); err == nil || err.Error() != "too many arguments: c" {
+		t.Errorf("got %v want too many arguments\n", err)
+	}
+}
+
+func TestPositionalUsage(t *testing.T) {
+	var buf bytes.Buffer
+	fs, _, _ := newCopyFlagSet(&buf)
+	fs.NamedArg("mode", "file mode").NewString("")
+	fs.Parse([]string{"-h"})
+
+	help := buf.String()
+	if !strings.HasPrefix(help, "Usage of cp: [options] SRC... DST [MODE]\n") {
+		t.Errorf("got %q\n", help)
+	}
+
+	if !strings.Contains(help, "\nArguments:\n  SRC...\n    \tsource files\n") {
+		t.Errorf("help should list the arguments, got %q\n", help)
+	}
+}
+
+func TestPositionalStruct(t *testing.T) {
+	type option struct {
+		Recursive bool     `opt:"r, recursive" usage:"copy directories recursively"`
+		Src       []string `arg:"rest" usage:"source files" required:"true"`
+		Dst       string   `arg:"0" usage:"destination" required:"true"`
+	}
+
+	fs := NewFlagSet("cp", ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	var o option
+	if err := fs.ParseStruct([]string{"-r", "dir", "a", "b"}, &o); err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	want := option{Recursive: true, Src: []string{"a", "b"}, Dst: "dir"}
+	if !reflect.DeepEqual(o, want) {
+		t.Errorf("got %+v want %+v\n", o, want)
+	}
+}
+
+func TestPositionalStructIndex(t *testing.T) {
+	for _, s := range []interface{}{
+		&struct {
+			A string `arg:"1"`
+			B string `arg:"5"`
+		}{},
+		&struct {
+			A string `arg:"0"`
+			B string `arg:"0"`
+		}{},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%T: arg tags with gaps or duplicates should panic\n", s)
+				}
+			}()
+			NewFlagSet("test", ContinueOnError).ParseStruct(nil, s)
+		}()
+	}
+}
@@ -0,0 +1,41 @@
+package flag
+
+// checkFlags runs the checks done once all sources are parsed. It returns
+// the first problem, or all of them while ParseAll collects the errors.
+// The flags named in failed got an invalid value and are not reported
+// as missing.
+func (f *FlagSet) checkFlags(failed map[string]bool) []error {
+	var errs []error
+	if err := f.checkRequired(failed); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 || f.collecting {
+		errs = append(errs, f.checkGroups()...)
+	}
+
+	if len(errs) == 0 || f.collecting {
+		errs = append(errs, f.checkMinLen()...)
+	}
+	return errs
+}
+
+// checkRequired reports the flags marked Required that no source has set.
+func (f *FlagSet) checkRequired(failed map[string]bool) error {
+	var missing []string
+	check := func(set *FlagSet, flag *Flag) {
+		if flag.required && !f.isSet(flag) && !failed[longestName(flag)] {
+			missing = append(missing, longestName(flag))
+		}
+	}
+
+	for _, flag := range sortFlags(f.formal) {
+		check(f, flag)
+	}
+	visitGlobal(f.persistent, f.formal, check)
+
+	if len(missing) == 0 {
+		return nil
+	}
+	return f.fail(&MissingRequiredError{Flags: missing})
+}
@@ -0,0 +1,55 @@
+package flag
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRequired(t *testing.T) {
+	var buf bytes.Buffer
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(&buf)
+	fs.Opt("p, port", "listen port").Required().NewInt(0)
+	fs.Opt("host", "server host").Required().NewString("")
+	fs.Opt("d, debug", "debug mode").NewBool(false)
+
+	err := fs.Parse([]string{"-d"})
+	e, ok := err.(*MissingRequiredError)
+	if !ok {
+		t.Fatalf("got %v want *MissingRequiredError\n", err)
+	}
+
+	if !reflect.DeepEqual(e.Flags, []string{"host", "port"}) || err.Error() != "missing required flags: -host, -port" {
+		t.Errorf("got %v\n", err)
+	}
+
+	if !strings.Contains(buf.String(), "listen port (required)") {
+		t.Errorf("help should mark required flags, got %q\n", buf.String())
+	}
+
+	os.Setenv("REQUIRED_TEST_HOST", "example.com")
+	defer os.Unsetenv("REQUIRED_TEST_HOST")
+
+	fs.SetEnvPrefix("REQUIRED_TEST")
+	if err := fs.Parse([]string{"-p", "80"}); err != nil {
+		t.Errorf("got %v want nil\n", err)
+	}
+}
+
+func TestRequiredStruct(t *testing.T) {
+	type option struct {
+		Port int    `opt:"p, port" usage:"listen port" required:"true"`
+		Host string `opt:"host" usage:"server host"`
+	}
+
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	var o option
+	err := fs.ParseStruct([]string{"-host", "example.com"}, &o)
+	if e, ok := err.(*MissingRequiredError); !ok || !reflect.DeepEqual(e.Flags, []string{"port"}) {
+		t.Errorf("got %v want missing -port\n", err)
+	}
+}
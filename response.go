@@ -0,0 +1,224 @@
+package flag
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// EnableResponseFiles makes Parse replace each argument of the form @file
+// by the arguments read from file, which lets a command take more
+// arguments than the system allows on a command line.
+//
+// The file is split into words like a shell does: words are separated by
+// blanks and newlines, single quotes keep everything literally, double
+// quotes keep blanks, a backslash escapes the next character and a word
+// starting with # comments out the rest of the line. An unquoted word of
+// the form @file in a response file is expanded too, relative to the
+// directory of that response file; quote it, '@file', to keep it as is.
+// Arguments after "--" are not expanded.
+func (f *FlagSet) EnableResponseFiles() *FlagSet {
+	f.responseFiles = true
+	return f
+}
+
+// EnableResponseFiles makes Parse expand @file arguments of the command line.
+func EnableResponseFiles() {
+	CommandLine.EnableResponseFiles()
+}
+
+// responseFile returns the name of the response file referenced by arg.
+func responseFile(arg string) (string, bool) {
+	if len(arg) < 2 || arg[0] != '@' {
+		return "", false
+	}
+	return arg[1:], true
+}
+
+// expandResponseFiles returns arguments with the response files expanded.
+func expandResponseFiles(arguments []string) ([]string, error) {
+	e := responseExpander{}
+	for k, arg := range arguments {
+		if arg == "--" {
+			return append(e.args, arguments[k:]...), nil
+		}
+
+		name, ok := responseFile(arg)
+		if !ok {
+			e.args = append(e.args, arg)
+			continue
+		}
+
+		if err := e.expand(name); err != nil {
+			if _, ok := err.(*ResponseFileError); !ok {
+				err = &ResponseFileError{Err: err} // the file of the command line itself
+			}
+			return nil, err
+		}
+
+		if e.terminated {
+			return append(e.args, arguments[k+1:]...), nil
+		}
+	}
+	return e.args, nil
+}
+
+// responseExpander collects the arguments read from response files.
+type responseExpander struct {
+	args       []string
+	stack      []string // absolute names of the files being read
+	terminated bool     // "--" was read
+}
+
+// expand appends the arguments of the response file name to e.args.
+func (e *responseExpander) expand(name string) error {
+	path, err := filepath.Abs(name)
+	if err != nil {
+		return err
+	}
+
+	for k, p := range e.stack {
+		if p == path {
+			cycle := append(append([]string{}, e.stack[k:]...), path)
+			return fmt.Errorf("response file cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	words, line, err := splitResponseFile(string(data))
+	if err != nil {
+		return &ResponseFileError{File: name, Line: line, Err: err}
+	}
+
+	e.stack = append(e.stack, path)
+	defer func() { e.stack = e.stack[:len(e.stack)-1] }()
+
+	for _, w := range words {
+		if e.terminated {
+			e.args = append(e.args, w.text)
+			continue
+		}
+
+		if w.text == "--" && !w.quoted {
+			e.terminated = true
+			e.args = append(e.args, w.text)
+			continue
+		}
+
+		nested, ok := responseFile(w.text)
+		if !ok || w.quoted {
+			e.args = append(e.args, w.text)
+			continue
+		}
+
+		if !filepath.IsAbs(nested) {
+			nested = filepath.Join(filepath.Dir(name), nested)
+		}
+
+		if err := e.expand(nested); err != nil {
+			if _, ok := err.(*ResponseFileError); ok {
+				return err
+			}
+			return &ResponseFileError{File: name, Line: w.line, Err: err}
+		}
+	}
+	return nil
+}
+
+// responseWord is a word of a response file.
+type responseWord struct {
+	text   string
+	line   int  // line where the word starts, from 1
+	quoted bool // the word has quotes or escapes
+}
+
+// splitResponseFile splits data into words the way a shell does.
+// On error it returns the line where the problem starts.
+func splitResponseFile(data string) ([]responseWord, int, error) {
+	var words []responseWord
+	var word []byte
+	inWord := false
+	w := responseWord{}
+	line := 1
+
+	end := func() {
+		if inWord {
+			w.text = string(word)
+			words = append(words, w)
+		}
+		word, inWord = word[:0], false
+	}
+
+	start := func() {
+		if !inWord {
+			w = responseWord{line: line}
+			inWord = true
+		}
+	}
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '\n':
+			end()
+			line++
+
+		case c == ' ' || c == '\t' || c == '\r':
+			end()
+
+		case c == '#' && !inWord:
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			i-- // the newline ends the comment
+
+		case c == '\\' && i+1 < len(data) && data[i+1] == '\n':
+			i++ // line continuation
+			line++
+
+		case c == '\\':
+			start()
+			w.quoted = true
+			if i+1 < len(data) {
+				i++
+			}
+			word = append(word, data[i])
+
+		case c == '\'' || c == '"':
+			start()
+			w.quoted = true
+			quoteLine := line
+			for i++; ; i++ {
+				if i == len(data) {
+					return nil, quoteLine, fmt.Errorf("unterminated %c quote", c)
+				}
+
+				if data[i] == c {
+					break
+				}
+
+				if data[i] == '\n' {
+					line++
+				}
+
+				// in double quotes a backslash only escapes \ and "
+				if c == '"' && data[i] == '\\' && i+1 < len(data) && (data[i+1] == '\\' || data[i+1] == '"') {
+					i++
+				}
+				word = append(word, data[i])
+			}
+
+		default:
+			start()
+			word = append(word, c)
+		}
+	}
+
+	end()
+	return words, 0, nil
+}
@@ -0,0 +1,114 @@
+package flag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitResponseFile(t *testing.T) {
+	data := "-v # verbose\n" +
+		"--name 'a b' \"c \\\"d\\\"\" e\\ f\n" +
+		"# a comment line\n" +
+		"x\\\n  y '' \\#z\n"
+
+	words, _, err := splitResponseFile(data)
+	if err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	var got []string
+	var lines []int
+	for _, w := range words {
+		got = append(got, w.text)
+		lines = append(lines, w.line)
+	}
+
+	want := []string{"-v", "--name", "a b", `c "d"`, "e f", "x", "y", "", "#z"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q want %q\n", got, want)
+	}
+
+	if !reflect.DeepEqual(lines, []int{1, 2, 2, 2, 2, 4, 5, 5, 5}) {
+		t.Errorf("lines got %v\n", lines)
+	}
+
+	_, line, err := splitResponseFile("a\nb 'c\nd")
+	if err == nil || line != 2 || err.Error() != "unterminated ' quote" {
+		t.Errorf("got %v at line %d want unterminated quote at line 2\n", err, line)
+	}
+}
+
+func TestResponseFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "args.txt"), []byte("-p 8080\n@more.txt\nfile1 '@user' \\@group\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "more.txt"), []byte("--host 'example.com'\n"), 0644)
+
+	fs := NewFlagSet("build", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.EnableResponseFiles()
+	port := fs.Opt("p, port", "listen port").NewInt(80)
+	host := fs.Opt("host", "server host").NewString("localhost")
+
+	err = fs.Parse([]string{"@" + filepath.Join(dir, "args.txt"), "file2", "--", "@file3"})
+	if err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	if *port != 8080 || *host != "example.com" {
+		t.Errorf("got %d %s\n", *port, *host)
+	}
+
+	if !reflect.DeepEqual(fs.Args(), []string{"file1", "@user", "@group", "file2", "@file3"}) {
+		t.Errorf("args got %v\n", fs.Args())
+	}
+}
+
+func TestResponseFilesError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	a, b := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	ioutil.WriteFile(a, []byte("-v\n@b.txt\n"), 0644)
+	ioutil.WriteFile(b, []byte("\n\n@a.txt\n"), 0644)
+
+	fs := NewFlagSet("build", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.EnableResponseFiles()
+	fs.Bool("v", false, "verbose")
+
+	err = fs.Parse([]string{"@" + a})
+	e, ok := err.(*ResponseFileError)
+	if !ok || e.File != b || e.Line != 3 || !strings.Contains(err.Error(), "response file cycle") {
+		t.Errorf("got %v want cycle error at %s:3\n", err, b)
+	}
+
+	ioutil.WriteFile(b, []byte("-v\n@missing.txt\n"), 0644)
+	err = fs.Parse([]string{"@" + a})
+	if e, ok := err.(*ResponseFileError); !ok || e.File != b || e.Line != 2 || !os.IsNotExist(e.Err) {
+		t.Errorf("got %v want not exist error at %s:2\n", err, b)
+	}
+
+	missing := filepath.Join(dir, "missing.txt")
+	err = fs.Parse([]string{"@" + missing})
+	if e, ok := err.(*ResponseFileError); !ok || e.File != "" || !os.IsNotExist(e.Err) || !strings.Contains(err.Error(), missing) {
+		t.Errorf("got %#v want *ResponseFileError for %s\n", err, missing)
+	}
+
+	fs = NewFlagSet("build", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	if err := fs.Parse([]string{"@" + a}); err != nil || !reflect.DeepEqual(fs.Args(), []string{"@" + a}) {
+		t.Errorf("response files are off by default, got %v %v\n", err, fs.Args())
+	}
+}
@@ -2,8 +2,11 @@ package flag
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -20,6 +23,12 @@ func parseFlags(s string) (f Flags) {
 			f |= GreedyMode
 		case "notValue", "NotValue":
 			f |= NotValue
+		case "fileName", "FileName":
+			f |= FileName
+		case "negatable", "Negatable":
+			f |= Negatable
+		case "optionalValue", "OptionalValue":
+			f |= OptionalValue
 		}
 	}
 	return
@@ -134,6 +143,44 @@ func parseDefValue(v reflect.Value, defValue string, sep string) (rv interface{}
 	return
 }
 
+// parseBound parses the min or max tag; def is used when the tag is empty.
+func parseBound(s string, def float64) float64 {
+	if s == "" {
+		return def
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		panic(err.Error())
+	}
+	return n
+}
+
+// bindBounds applies the min and max tags of the field sf: a Range for
+// numbers, a MinLen for slices and strings.
+func bindBounds(flag *Flag, sf reflect.StructField, min, max string) {
+	switch sf.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		flag.Range(parseBound(min, math.Inf(-1)), parseBound(max, math.Inf(1)))
+
+	case reflect.Slice, reflect.String:
+		if max != "" {
+			panic(fmt.Sprintf("%s: the max tag needs a number", sf.Name))
+		}
+
+		n, err := strconv.Atoi(min)
+		if err != nil {
+			panic(err.Error())
+		}
+		flag.MinLen(n)
+
+	default:
+		panic(fmt.Sprintf("%s: the min and max tags need a number, a slice or a string", sf.Name))
+	}
+}
+
 func (f *FlagSet) parseStruct(v reflect.Value) bool {
 
 	if v.Kind() == reflect.Ptr {
@@ -141,6 +188,7 @@ func (f *FlagSet) parseStruct(v reflect.Value) bool {
 	}
 	st := v.Type()
 
+	var args []structArg
 	for i := 0; i < st.NumField(); i++ {
 		sf := st.Field(i)
 
@@ -148,34 +196,113 @@ func (f *FlagSet) parseStruct(v reflect.Value) bool {
 			continue
 		}
 
+		// subcommands are bound by ParseCommandStruct
+		if sf.Tag.Get("subcommand") != "" {
+			continue
+		}
+
 		sv := v.Field(i)
 		if sv.Kind() == reflect.Struct {
 			f.parseStruct(sv)
 			continue
 		}
 
+		if arg := sf.Tag.Get("arg"); arg != "" {
+			args = append(args, newStructArg(arg, sf, sv))
+			continue
+		}
+
 		opt := sf.Tag.Get("opt")
 		usage := sf.Tag.Get("usage")
 		defValue := sf.Tag.Get("defValue")
 		flags := sf.Tag.Get("flags")
+		env := sf.Tag.Get("env")
 
 		if opt == "" || usage == "" {
 			continue
 		}
 
+		flag := f.Opt(opt, usage).
+			Flags(parseFlags(flags)).
+			Env(env)
+
+		if sf.Tag.Get("required") == "true" {
+			flag.Required()
+		}
+
+		if noOptDefVal := sf.Tag.Get("noOptDefVal"); noOptDefVal != "" {
+			flag.NoOptDefault(noOptDefVal)
+		}
+
+		if enum := sf.Tag.Get("enum"); enum != "" {
+			flag.Enum(strings.Split(enum, "|")...)
+		}
+
+		min, max := sf.Tag.Get("min"), sf.Tag.Get("max")
+		if min != "" || max != "" {
+			bindBounds(flag, sf, min, max)
+		}
+
+		if pattern := sf.Tag.Get("pattern"); pattern != "" {
+			flag.Match(regexp.MustCompile(pattern))
+		}
+
 		if defValue != "" {
-			f.Opt(opt, usage).
-				Flags(parseFlags(flags)).
-				DefaultVar(sv.Addr().Interface(), parseDefValue(sv, defValue, sf.Tag.Get("sep")))
+			flag.DefaultVar(sv.Addr().Interface(), parseDefValue(sv, defValue, sf.Tag.Get("sep")))
 		} else {
-			f.Opt(opt, usage).
-				Flags(parseFlags(flags)).
-				Var(sv.Addr().Interface())
+			flag.Var(sv.Addr().Interface())
+		}
+	}
+
+	sort.SliceStable(args, func(i, j int) bool { return args[i].index < args[j].index })
+	for k, arg := range args {
+		if arg.index != math.MaxInt32 && arg.index != k {
+			panic(fmt.Sprintf("%s: arg tags must number the positional arguments from 0 without gaps or duplicates", arg.field.Name))
 		}
+		arg.bind(f)
 	}
 	return true
 }
 
+// structArg is a field of a struct tagged arg:"N" or arg:"rest".
+type structArg struct {
+	index int // position, math.MaxInt32 for rest
+	field reflect.StructField
+	value reflect.Value
+}
+
+func newStructArg(tag string, sf reflect.StructField, sv reflect.Value) structArg {
+	index := math.MaxInt32
+	if tag != "rest" {
+		var err error
+		if index, err = strconv.Atoi(tag); err != nil {
+			panic(fmt.Sprintf("%s: invalid arg tag %q", sf.Name, tag))
+		}
+	}
+	return structArg{index: index, field: sf, value: sv}
+}
+
+// bind declares the positional argument of the field in f.
+func (a structArg) bind(f *FlagSet) {
+	name := strings.ToLower(a.field.Name)
+	usage := a.field.Tag.Get("usage")
+
+	flag := f.NamedArg(name, usage)
+	if a.index == math.MaxInt32 {
+		flag = f.ArgSlice(name, usage)
+	}
+
+	if a.field.Tag.Get("required") == "true" {
+		flag.Required()
+	}
+
+	if defValue := a.field.Tag.Get("defValue"); defValue != "" {
+		flag.DefaultVar(a.value.Addr().Interface(), parseDefValue(a.value, defValue, a.field.Tag.Get("sep")))
+	} else {
+		flag.Var(a.value.Addr().Interface())
+	}
+}
+
 func (f *FlagSet) ParseStruct(arguments []string, s interface{}) error {
 
 	v := reflect.ValueOf(s)
@@ -193,3 +320,101 @@ func ParseStruct(s interface{}) {
 	// Ignore errors; CommandLine is set for ExitOnError
 	CommandLine.ParseStruct(os.Args[1:], s)
 }
+
+// runner is implemented by the option structs of subcommands that have
+// something to do once their flags are parsed.
+type runner interface {
+	Run() error
+}
+
+// hasSubCommand reports whether the struct type st has fields tagged subcommand.
+func hasSubCommand(st reflect.Type) bool {
+	for i := 0; i < st.NumField(); i++ {
+		if st.Field(i).Tag.Get("subcommand") != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ParentCommand) parseCommandStruct(v reflect.Value) {
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	st := v.Type()
+
+	// the options of the command itself apply to all its subcommands
+	p.PersistentFlags().parseStruct(v)
+
+	for i := 0; i < st.NumField(); i++ {
+		sf := st.Field(i)
+		name := sf.Tag.Get("subcommand")
+		if name == "" || sf.PkgPath != "" {
+			continue
+		}
+
+		sv := v.Field(i)
+		if sv.Kind() == reflect.Ptr && sv.Type().Elem().Kind() == reflect.Struct {
+			if sv.IsNil() {
+				sv.Set(reflect.New(sv.Type().Elem()))
+			}
+			sv = sv.Elem()
+		}
+
+		if sv.Kind() != reflect.Struct {
+			panic(fmt.Sprintf("subcommand %s: field %s must be a structure", name, sf.Name))
+		}
+
+		usage := sf.Tag.Get("usage")
+		if hasSubCommand(sv.Type()) {
+			p.SubParentCommand(name, usage).parseCommandStruct(sv)
+			continue
+		}
+
+		opt := sv.Addr().Interface()
+		fs := p.Command(name, usage, func(*FlagSet) error {
+			if r, ok := opt.(runner); ok {
+				return r.Run()
+			}
+			return nil
+		})
+		fs.parseStruct(sv)
+	}
+}
+
+// ParseCommandStruct builds the subcommands of p from s, a structure
+// pointer, and parses arguments with them. Each field of s tagged
+// subcommand:"name" is a structure holding the options of that subcommand,
+// bound like ParseStruct does; a structure with subcommand fields of its own
+// describes nested subcommands. The other options of s are persistent flags.
+// The subcommand selected on the command line is run by calling its Run
+// method, if the structure has one:
+//
+//	type httpOption struct {
+//		URL string `opt:"url" usage:"Specify a URL to fetch"`
+//	}
+//
+//	func (h *httpOption) Run() error { ... }
+//
+//	type option struct {
+//		Verbose bool       `opt:"v, verbose" usage:"verbose output"`
+//		HTTP    httpOption `subcommand:"http" usage:"Use the http subcommand"`
+//	}
+func (p *ParentCommand) ParseCommandStruct(arguments []string, s interface{}) error {
+
+	v := reflect.ValueOf(s)
+
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		panic("The argument to the function must be a structure pointer")
+	}
+
+	p.parseCommandStruct(v)
+
+	return p.Parse(arguments)
+}
+
+// ParseCommandStruct builds a ParentCommand named after the program from s
+// and parses the command-line arguments with it.
+func ParseCommandStruct(s interface{}) error {
+	return NewParentCommand(os.Args[0]).ParseCommandStruct(os.Args[1:], s)
+}
@@ -1,6 +1,8 @@
 package flag
 
 import (
+	"bytes"
+	"reflect"
 	"sort"
 	"testing"
 	"time"
@@ -143,3 +145,86 @@ func TestStructParse(t *testing.T) {
 		t.Errorf("got %v want 6\n", o.M)
 	}
 }
+
+type commandHTTPOption struct {
+	URL string `opt:"url" usage:"Specify a URL to fetch"`
+	ran bool
+}
+
+func (h *commandHTTPOption) Run() error {
+	h.ran = true
+	return nil
+}
+
+type commandNodeOption struct {
+	Name string `opt:"n, name" usage:"node name"`
+}
+
+type commandClusterOption struct {
+	Add commandNodeOption `subcommand:"add" usage:"Add a node"`
+}
+
+type commandOption struct {
+	Verbose bool                  `opt:"v, verbose" usage:"verbose output"`
+	HTTP    commandHTTPOption     `subcommand:"http" usage:"Use the http subcommand"`
+	Cluster *commandClusterOption `subcommand:"cluster" usage:"Manage clusters"`
+}
+
+func TestParseCommandStruct(t *testing.T) {
+	o := commandOption{}
+	parent := NewParentCommand("tool")
+	err := parent.ParseCommandStruct([]string{"http", "-url", "http://example.com", "-v"}, &o)
+	if err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	if !o.HTTP.ran || o.HTTP.URL != "http://example.com" || !o.Verbose {
+		t.Errorf("got %+v\n", o)
+	}
+
+	o = commandOption{}
+	parent = NewParentCommand("tool")
+	err = parent.ParseCommandStruct([]string{"cluster", "add", "-n", "n1"}, &o)
+	if err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	if o.HTTP.ran || o.Cluster == nil || o.Cluster.Add.Name != "n1" {
+		t.Errorf("got %+v\n", o)
+	}
+}
+
+func TestStructEnum(t *testing.T) {
+	type option struct {
+		Format string `opt:"format" usage:"output format" enum:"json|yaml"`
+	}
+
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	var o option
+	if err := fs.ParseStruct([]string{"-format", "xml"}, &o); err == nil {
+		t.Errorf("got nil want error\n")
+	}
+
+	if flagEnum(fs.Lookup("format")) == nil {
+		t.Errorf("enum should be used by completion\n")
+	}
+}
+
+func TestStructOptionalValue(t *testing.T) {
+	type option struct {
+		Color string `opt:"color" usage:"colorize the output" defValue:"never" noOptDefVal:"auto"`
+		Level int    `opt:"level" usage:"log level" noOptDefVal:"3"`
+	}
+
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	var o option
+	if err := fs.ParseStruct([]string{"--color", "--level", "file"}, &o); err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	if o.Color != "auto" || o.Level != 3 || !reflect.DeepEqual(fs.Args(), []string{"file"}) {
+		t.Errorf("got %+v args %v\n", o, fs.Args())
+	}
+}
@@ -16,12 +16,21 @@ type ParentCommand struct {
 	subCommand2 map[string]*subCommand
 	args        []string
 	maxName     int
+	parent      *ParentCommand
+	flags       *FlagSet // persistent flags, see PersistentFlags
+
+	abbreviations bool
 }
 
 type subCommand struct {
 	Name       string
 	Usage      string
 	SubProcess func()
+	child      *ParentCommand // set for nested subcommands
+
+	// set for subcommands created by Command
+	flagSet *FlagSet
+	run     func(*FlagSet) error
 }
 
 func NewParentCommand(name string) *ParentCommand {
@@ -77,10 +86,14 @@ func (p *ParentCommand) defaultUsage() {
 	}
 
 	p.PrintDefaults()
+	printGlobalDefaults(p.Output(), p.persistentFlags(), nil)
 }
 
 func (p *ParentCommand) Output() io.Writer {
 	if p.output == nil {
+		if p.parent != nil {
+			return p.parent.Output()
+		}
 		return os.Stderr
 	}
 
@@ -91,7 +104,7 @@ func (p *ParentCommand) SetOutput(output io.Writer) {
 	p.output = output
 }
 
-func (p *ParentCommand) saveSubCommand(sub map[string]*subCommand, name string, usage string, subProcess func()) {
+func (p *ParentCommand) saveSubCommand(sub map[string]*subCommand, name string, s subCommand) {
 	_, alreadythere := sub[name]
 	if alreadythere {
 		msg := ""
@@ -109,11 +122,12 @@ func (p *ParentCommand) saveSubCommand(sub map[string]*subCommand, name string,
 		p.maxName = len(name)
 	}
 
-	sub[name] = &subCommand{Name: name, Usage: usage, SubProcess: subProcess}
+	s.Name = name
+	sub[name] = &s
 }
 
-func (p *ParentCommand) SubCommand(name string, usage string, subProcess func()) {
-
+// subCommandNames splits the comma-separated name list, shortest first.
+func subCommandNames(name string) (string, []string) {
 	names := strings.Split(name, ",")
 
 	if len(names) > 1 {
@@ -126,11 +140,56 @@ func (p *ParentCommand) SubCommand(name string, usage string, subProcess func())
 		name = strings.Join(names, ", ")
 	}
 
-	p.saveSubCommand(p.subCommand, name, usage, subProcess)
+	return name, names
+}
+
+func (p *ParentCommand) addSubCommand(name string, s subCommand) {
+	name, names := subCommandNames(name)
+
+	p.saveSubCommand(p.subCommand, name, s)
 
 	for _, name := range names {
-		p.saveSubCommand(p.subCommand2, name, usage, subProcess)
+		p.saveSubCommand(p.subCommand2, name, s)
+	}
+}
+
+func (p *ParentCommand) SubCommand(name string, usage string, subProcess func()) {
+	p.addSubCommand(name, subCommand{Usage: usage, SubProcess: subProcess})
+}
+
+// subCommandPath returns the name of a subcommand prefixed by the
+// command path of p.
+func (p *ParentCommand) subCommandPath(name string) string {
+	_, names := subCommandNames(name)
+
+	path := names[len(names)-1]
+	if p.name != "" {
+		path = p.name + " " + path
 	}
+	return path
+}
+
+// Command registers a subcommand that owns a FlagSet, named after the full
+// command path, for instance "tool http". Define the flags of the subcommand
+// on the returned FlagSet. Parse parses the remaining arguments with it and
+// then calls run, if not nil, with the parsed FlagSet; the error returned by
+// run is returned by Parse.
+func (p *ParentCommand) Command(name string, usage string, run func(*FlagSet) error) *FlagSet {
+	fs := NewFlagSet(p.subCommandPath(name), ContinueOnError)
+
+	p.addSubCommand(name, subCommand{Usage: usage, flagSet: fs, run: run})
+	return fs
+}
+
+// SubParentCommand registers a subcommand that has subcommands of its own,
+// such as cluster in "tool cluster node add". The returned ParentCommand is
+// named after the full command path, which appears in its help and errors.
+func (p *ParentCommand) SubParentCommand(name string, usage string) *ParentCommand {
+	child := NewParentCommand(p.subCommandPath(name))
+	child.parent = p
+
+	p.addSubCommand(name, subCommand{Usage: usage, child: child})
+	return child
 }
 
 func (p *ParentCommand) Args() []string { return p.args }
@@ -158,7 +217,6 @@ func (p *ParentCommand) parseOne() (bool, error) {
 	}
 
 	name := s[numMinuses:]
-
 	m := p.subCommand
 	sub, alreadythere := m[name]
 
@@ -168,21 +226,54 @@ func (p *ParentCommand) parseOne() (bool, error) {
 			return false, ErrHelp
 		}
 
+		// only plain words are abbreviated, -h is never -http
+		if numMinuses == 0 {
+			var err error
+			if name, err = p.expandSubCommand(name); err != nil {
+				return false, p.fail(err)
+			}
+		}
+
 		sub, alreadythere = p.subCommand2[name]
 		if !alreadythere {
-			return false, p.failf("subcommand provided but not defined: -%s", name)
+			return false, p.fail(&UnknownSubcommandError{Name: name, Suggestions: p.subCommandSuggestions(name)})
 		}
 	}
 
 	p.args = p.args[1:]
 
+	if sub.child != nil {
+		return true, sub.child.Parse(p.args)
+	}
+
+	if fs := sub.flagSet; fs != nil {
+		if fs.output == nil {
+			fs.SetOutput(p.Output())
+		}
+		p.inherit(fs)
+
+		if err := fs.Parse(p.args); err != nil {
+			return false, err
+		}
+
+		if sub.run == nil {
+			return true, nil
+		}
+		return true, sub.run(fs)
+	}
+
+	args, err := p.extractPersistent(p.args)
+	if err != nil {
+		return false, err
+	}
+	p.args = args
+
 	sub.SubProcess()
 
 	return true, nil
 }
 
-func (p *ParentCommand) failf(format string, a ...interface{}) error {
-	err := fmt.Errorf(format, a...)
+func (p *ParentCommand) fail(err error) error {
 	fmt.Fprintln(p.Output(), err)
 	p.usage()
 	return err
@@ -190,11 +281,15 @@ func (p *ParentCommand) failf(format string, a ...interface{}) error {
 
 func (p *ParentCommand) Parse(arguments []string) error {
 
-	p.args = arguments
+	if len(arguments) > 0 && arguments[0] == completeCommand {
+		return p.complete(arguments[1:])
+	}
 
-	for {
-		_, err := p.parseOne()
+	p.args = arguments
+	if err := p.parsePersistent(); err != nil {
 		return err
 	}
-	return nil
+
+	_, err := p.parseOne()
+	return err
 }
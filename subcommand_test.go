@@ -1,6 +1,11 @@
 package flag
 
 import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -66,3 +71,177 @@ func TestSubCommand(t *testing.T) {
 		t.Error("rm should be true")
 	}
 }
+
+func TestSubParentCommand(t *testing.T) {
+	var buf bytes.Buffer
+	parent := NewParentCommand("tool")
+	parent.SetOutput(&buf)
+
+	cluster := parent.SubParentCommand("cluster", "Manage clusters")
+	node := cluster.SubParentCommand("n, node", "Manage cluster nodes")
+
+	var args []string
+	node.SubCommand("add", "Add a node", func() {
+		args = node.Args()
+	})
+
+	if err := parent.Parse([]string{"cluster", "n", "add", "-name", "n1"}); err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	if len(args) != 2 || args[0] != "-name" || args[1] != "n1" {
+		t.Errorf("args got %v want [-name n1]\n", args)
+	}
+
+	if len(cluster.Args()) != 3 || cluster.Args()[0] != "add" {
+		t.Errorf("cluster args got %v want [add -name n1]\n", cluster.Args())
+	}
+
+	if err := parent.Parse([]string{"cluster", "node", "-h"}); err != ErrHelp {
+		t.Errorf("got %v want ErrHelp\n", err)
+	}
+
+	if !strings.Contains(buf.String(), "Usage of tool cluster node:") {
+		t.Errorf("help should show the command path, got %q\n", buf.String())
+	}
+
+	buf.Reset()
+	if err := parent.Parse([]string{"cluster", "nod"}); err == nil {
+		t.Errorf("unknown subcommand should fail\n")
+	}
+
+	if !strings.Contains(buf.String(), "Usage of tool cluster:") {
+		t.Errorf("error should show the command path, got %q\n", buf.String())
+	}
+}
+
+func TestCommand(t *testing.T) {
+	var buf bytes.Buffer
+	parent := NewParentCommand("tool")
+	parent.SetOutput(&buf)
+
+	var (
+		gotURL  string
+		gotArgs []string
+	)
+
+	http := parent.Command("http", "Use the http subcommand", func(fs *FlagSet) error {
+		gotArgs = fs.Args()
+		if gotURL == "" {
+			return errors.New("url is empty")
+		}
+		return nil
+	})
+	http.Opt("url", "Specify a URL to fetch").Var(&gotURL)
+
+	if err := parent.Parse([]string{"http", "-url", "http://example.com", "file"}); err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	if gotURL != "http://example.com" || len(gotArgs) != 1 || gotArgs[0] != "file" {
+		t.Errorf("got url %s args %v\n", gotURL, gotArgs)
+	}
+
+	if http.Name() != "tool http" {
+		t.Errorf("name got %s want tool http\n", http.Name())
+	}
+
+	gotURL = ""
+	if err := parent.Parse([]string{"http"}); err == nil || err.Error() != "url is empty" {
+		t.Errorf("got %v want the error of the handler\n", err)
+	}
+
+	if err := parent.Parse([]string{"http", "-h"}); err != ErrHelp {
+		t.Errorf("got %v want ErrHelp\n", err)
+	}
+
+	if !strings.Contains(buf.String(), "Usage of tool http:") {
+		t.Errorf("help should show the command path, got %q\n", buf.String())
+	}
+}
+
+func TestPersistentFlags(t *testing.T) {
+	var buf bytes.Buffer
+	parent := NewParentCommand("tool")
+	parent.SetOutput(&buf)
+	verbose := parent.PersistentFlags().Opt("v, verbose", "verbose output").NewBool(false)
+	config := parent.PersistentFlags().Opt("config", "config `file`").NewString("")
+
+	http := parent.Command("http", "Use the http subcommand", nil)
+	url := http.Opt("url", "Specify a URL to fetch").NewString("")
+
+	var gotArgs []string
+	cluster := parent.SubParentCommand("cluster", "Manage clusters")
+	cluster.SubCommand("ls", "List clusters", func() {
+		gotArgs = cluster.Args()
+	})
+
+	err := parent.Parse([]string{"-v", "http", "--config", "a.yaml", "-url", "http://example.com"})
+	if err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	if !*verbose || *config != "a.yaml" || *url != "http://example.com" {
+		t.Errorf("got verbose %t config %s url %s\n", *verbose, *config, *url)
+	}
+
+	*verbose, *config = false, ""
+	err = parent.Parse([]string{"--config=b.yaml", "cluster", "ls", "-a", "-v", "x"})
+	if err != nil {
+		t.Fatalf("got %v want nil\n", err)
+	}
+
+	if !*verbose || *config != "b.yaml" {
+		t.Errorf("got verbose %t config %s\n", *verbose, *config)
+	}
+
+	if strings.Join(gotArgs, " ") != "-a x" {
+		t.Errorf("args got %v want [-a x]\n", gotArgs)
+	}
+
+	if err := parent.Parse([]string{"http", "-h"}); err != ErrHelp {
+		t.Errorf("got %v want ErrHelp\n", err)
+	}
+
+	help := buf.String()
+	if pos := strings.Index(help, "Global options:"); pos == -1 || !strings.Contains(help[pos:], "-v, --verbose") ||
+		strings.Contains(help[pos:], "--help") {
+		t.Errorf("help should list the persistent flags under Global options, got %q\n", help)
+	}
+
+	if err := parent.Parse([]string{"--unknown", "http"}); err == nil {
+		t.Errorf("unknown flag before the subcommand should fail\n")
+	}
+}
+
+func TestPersistentRequired(t *testing.T) {
+	newTool := func() (*ParentCommand, *bool) {
+		parent := NewParentCommand("tool")
+		parent.SetOutput(ioutil.Discard)
+		parent.PersistentFlags().Opt("token", "api token").Required().NewString("")
+
+		ran := new(bool)
+		parent.SubCommand("tcp", "Use the tcp subcommand", func() { *ran = true })
+		parent.Command("http", "Use the http subcommand", nil)
+		return parent, ran
+	}
+
+	for _, args := range [][]string{{"tcp"}, {"http"}} {
+		parent, ran := newTool()
+		err := parent.Parse(args)
+		if e, ok := err.(*MissingRequiredError); !ok || !reflect.DeepEqual(e.Flags, []string{"token"}) {
+			t.Errorf("%v: got %v want missing -token\n", args, err)
+		}
+
+		if *ran {
+			t.Errorf("%v: the subcommand should not run\n", args)
+		}
+	}
+
+	for _, args := range [][]string{{"tcp", "--token", "x"}, {"--token", "x", "tcp"}, {"http", "--token", "x"}} {
+		parent, _ := newTool()
+		if err := parent.Parse(args); err != nil {
+			t.Errorf("%v: got %v want nil\n", args, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package flag
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+)
+
+// flagValue returns the value of flag as given to the Validate functions:
+// the result of Get if the Value is a Getter, the Value itself otherwise.
+func flagValue(flag *Flag) interface{} {
+	if g, ok := flag.Value.(Getter); ok {
+		return g.Get()
+	}
+	return flag.Value
+}
+
+// Validate adds fn to the checks run each time the flag is set, with the
+// value of the flag. An error returned by fn is reported as an invalid value.
+func (f *Flag) Validate(fn func(v interface{}) error) *Flag {
+	f.validators = append(f.validators, fn)
+	return f
+}
+
+// numbers returns the numbers held by v, a number or a slice of numbers.
+func numbers(v interface{}) []float64 {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		rv = reflect.ValueOf([]interface{}{v})
+	}
+
+	var nums []float64
+	for i := 0; i < rv.Len(); i++ {
+		e := reflect.Indirect(reflect.ValueOf(rv.Index(i).Interface()))
+		switch e.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			nums = append(nums, float64(e.Int()))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			nums = append(nums, float64(e.Uint()))
+		case reflect.Float32, reflect.Float64:
+			nums = append(nums, e.Float())
+		}
+	}
+	return nums
+}
+
+// Range requires the value of a numeric flag, or every element of a
+// numeric slice flag, to lie between min and max inclusive. Use math.Inf
+// for an open bound.
+func (f *Flag) Range(min, max float64) *Flag {
+	return f.Validate(func(v interface{}) error {
+		for _, n := range numbers(v) {
+			if n >= min && n <= max {
+				continue
+			}
+
+			switch {
+			case math.IsInf(max, 1):
+				return fmt.Errorf("must be at least %v", min)
+			case math.IsInf(min, -1):
+				return fmt.Errorf("must be at most %v", max)
+			}
+			return fmt.Errorf("must be between %v and %v", min, max)
+		}
+		return nil
+	})
+}
+
+// Match requires the value of a string flag, or every element of a string
+// slice flag, to match re.
+func (f *Flag) Match(re *regexp.Regexp) *Flag {
+	return f.Validate(func(v interface{}) error {
+		var values []string
+		switch s := v.(type) {
+		case string:
+			values = []string{s}
+		case []string:
+			values = s
+		}
+
+		for _, s := range values {
+			if !re.MatchString(s) {
+				return fmt.Errorf("must match %s", re)
+			}
+		}
+		return nil
+	})
+}
+
+// MinLen requires a slice or string flag to hold at least n elements or
+// bytes. Unlike the other checks it runs once, at the end of Parse, as
+// slice flags grow with each occurrence on the command line.
+func (f *Flag) MinLen(n int) *Flag {
+	f.minLen = n
+	return f
+}
+
+// snapshot saves the variable v points to, as the Values of this package
+// do, and returns a function restoring it. Other Values are copied
+// shallowly.
+func snapshot(v Value) func() {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return func() {}
+	}
+
+	old := reflect.New(rv.Elem().Type()).Elem()
+	old.Set(rv.Elem())
+	return func() { rv.Elem().Set(old) }
+}
+
+// validate runs the Validate functions of flag.
+func (f *Flag) validate() error {
+	if len(f.validators) == 0 {
+		return nil
+	}
+
+	v := flagValue(f)
+	for _, fn := range f.validators {
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkMinLen checks the flags with a MinLen once all sources are parsed.
+// It stops at the first problem unless ParseAll collects the errors.
+func (f *FlagSet) checkMinLen() []error {
+	var errs []error
+	for _, flag := range sortFlags(f.formal) {
+		if flag.minLen == 0 {
+			continue
+		}
+
+		rv := reflect.ValueOf(flagValue(flag))
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.String {
+			continue
+		}
+
+		if rv.Len() < flag.minLen {
+			errs = append(errs, f.failf("flag -%s needs at least %d values, got %d", longestName(flag), flag.minLen, rv.Len()))
+			if !f.collecting {
+				break
+			}
+		}
+	}
+	return errs
+}
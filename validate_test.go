@@ -0,0 +1,120 @@
+package flag
+
+import (
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestValidators(t *testing.T) {
+	newFlagSet := func() *FlagSet {
+		fs := NewFlagSet("test", ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		fs.Opt("p, port", "listen port").Range(1, 65535).NewInt(80)
+		fs.Opt("ids", "ids").Range(0, 9).NewInt64Slice([]int64{})
+		fs.Opt("name", "name").Match(regexp.MustCompile(`^[a-z]+$`)).NewString("")
+		fs.Opt("H, header", "http header").MinLen(2).NewStringSlice([]string{})
+		fs.Opt("even", "even number").Validate(func(v interface{}) error {
+			if v.(int)%2 != 0 {
+				return errors.New("must be even")
+			}
+			return nil
+		}).NewInt(0)
+		return fs
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-H", "a", "-H", "b", "-p", "8080", "-ids", "1", "-ids", "2", "-name", "abc", "-even", "4"}, ""},
+		{[]string{"-H", "a", "-H", "b", "-p", "70000"}, `invalid value "70000" for flag -p: must be between 1 and 65535`},
+		{[]string{"-H", "a", "-H", "b", "-ids", "1", "-ids", "10"}, `invalid value "10" for flag -ids: must be between 0 and 9`},
+		{[]string{"-H", "a", "-H", "b", "-name", "ABC"}, `invalid value "ABC" for flag -name: must match ^[a-z]+$`},
+		{[]string{"-H", "a", "-H", "b", "-even", "3"}, `invalid value "3" for flag -even: must be even`},
+		{[]string{"-H", "a"}, "flag -header needs at least 2 values, got 1"},
+	}
+
+	for _, test := range tests {
+		err := newFlagSet().Parse(test.args)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+
+		if got != test.want {
+			t.Errorf("Parse(%q) got %q want %q\n", test.args, got, test.want)
+		}
+	}
+}
+
+func TestValidatorTags(t *testing.T) {
+	type option struct {
+		Port int    `opt:"port" usage:"listen port" min:"1"`
+		Name string `opt:"name" usage:"name" pattern:"^[a-z]+$"`
+	}
+
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	var o option
+	err := fs.ParseStruct([]string{"-port", "0"}, &o)
+	if err == nil || err.Error() != `invalid value "0" for flag -port: must be at least 1` {
+		t.Errorf("got %v\n", err)
+	}
+
+	fs = NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	if err := fs.ParseStruct([]string{"-name", "A"}, &o); err == nil {
+		t.Errorf("got nil want error\n")
+	}
+}
+
+func TestMinTagLen(t *testing.T) {
+	type option struct {
+		Tags []string `opt:"t, tag" usage:"tags" min:"1"`
+		Name string   `opt:"name" usage:"name" min:"2"`
+	}
+
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	var o option
+	err := fs.ParseStruct([]string{"-name", "ab"}, &o)
+	if err == nil || err.Error() != "flag -tag needs at least 1 values, got 0" {
+		t.Errorf("got %v\n", err)
+	}
+
+	fs = NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	err = fs.ParseStruct([]string{"-t", "a", "-name", "a"}, &o)
+	if err == nil || err.Error() != "flag -name needs at least 2 values, got 1" {
+		t.Errorf("got %v\n", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("max tag on a slice should panic\n")
+		}
+	}()
+
+	var bad struct {
+		Tags []string `opt:"t" usage:"tags" max:"3"`
+	}
+	NewFlagSet("test", ContinueOnError).ParseStruct(nil, &bad)
+}
+
+func TestValidateKeepsValue(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	port := fs.Opt("p, port", "listen port").Range(1, 65535).NewInt(80)
+	ids := fs.Opt("ids", "ids").Range(0, 9).NewInt64Slice([]int64{})
+
+	if err := fs.ParseAll([]string{"-p", "70000", "-ids", "1", "-ids", "10"}); err == nil {
+		t.Fatalf("got nil want error\n")
+	}
+
+	if *port != 80 || !reflect.DeepEqual(*ids, []int64{1}) {
+		t.Errorf("rejected values should not be stored, got port %d ids %v\n", *port, *ids)
+	}
+}